@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/joho/godotenv"
+	"github.com/yourusername/rbd-service/internal/config"
+	"github.com/yourusername/rbd-service/internal/logging"
+	"github.com/yourusername/rbd-service/internal/migrations"
+)
+
+// Usage: migrate <name>   (run without arguments to list migrations)
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Println("Usage: migrate <name>")
+		fmt.Println()
+		fmt.Println("Available migrations:")
+		for _, m := range migrations.All() {
+			fmt.Printf("  %-24s %s\n", m.Name, m.Description)
+		}
+		return
+	}
+
+	// Load environment variables (before the logger, so LOG_LEVEL can come from .env)
+	envErr := godotenv.Load()
+	logging.Init()
+	if envErr != nil {
+		slog.Info("no .env file found, using system environment variables")
+	}
+
+	// Initialize Firebase
+	if err := config.InitFirebase(); err != nil {
+		slog.Error("failed to initialize Firebase", "error", err)
+		os.Exit(1)
+	}
+
+	name := os.Args[1]
+	err := migrations.Run(context.Background(), config.FirestoreClient, name)
+	config.CloseFirebase()
+	if err != nil {
+		slog.Error("migration failed", "migration", name, "error", err)
+		os.Exit(1)
+	}
+	slog.Info("migration complete", "migration", name)
+}
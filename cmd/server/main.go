@@ -1,81 +1,197 @@
 package main
 
 import (
-	"log"
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
+	"github.com/yourusername/rbd-service/internal/audit"
 	"github.com/yourusername/rbd-service/internal/config"
 	"github.com/yourusername/rbd-service/internal/handlers"
+	"github.com/yourusername/rbd-service/internal/health"
+	"github.com/yourusername/rbd-service/internal/logging"
+	"github.com/yourusername/rbd-service/internal/metrics"
 	"github.com/yourusername/rbd-service/internal/middleware"
+	"github.com/yourusername/rbd-service/internal/models"
+	"github.com/yourusername/rbd-service/internal/services"
+	"github.com/yourusername/rbd-service/internal/storage"
+	"github.com/yourusername/rbd-service/internal/tracing"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 )
 
 func main() {
-	// Load environment variables
-	if err := godotenv.Load(); err != nil {
-		log.Println("No .env file found, using system environment variables")
+	// Load environment variables (before the logger, so LOG_LEVEL can come from .env)
+	envErr := godotenv.Load()
+	logging.Init()
+	if envErr != nil {
+		slog.Info("no .env file found, using system environment variables")
+	}
+
+	// Tracing is off unless TRACING_EXPORTER is set
+	shutdownTracing, err := tracing.Init(context.Background())
+	if err != nil {
+		slog.Error("failed to initialize tracing", "error", err)
+		os.Exit(1)
 	}
 
 	// Initialize Firebase
 	if err := config.InitFirebase(); err != nil {
-		log.Fatalf("Failed to initialize Firebase: %v", err)
+		slog.Error("failed to initialize Firebase", "error", err)
+		os.Exit(1)
 	}
-	defer config.CloseFirebase()
+
+	// Readiness probes; /readyz fails until startup completes
+	health.Register("firestore", 2*time.Second, health.CheckFirestore)
+	health.Register("messaging", 2*time.Second, health.CheckMessaging)
+	health.Register("workers", time.Second, health.CheckWorkers)
+	health.Register("search_index", time.Second, health.CheckSearchIndex)
+
+	// Background workers stop when the server shuts down
+	bg := newWorkers()
+
+	// Build the user search index in the background (/readyz waits for it) and keep rebuilding it
+	bg.Go(services.RunSearchIndexRefresh)
+
+	// Run account deletions once their grace period has passed
+	bg.Go(services.RunDeletionWorker)
+
+	// Reinstate users whose suspension has ended
+	bg.Go(services.RunSuspensionSweep)
+
+	// Drop audit entries older than AUDIT_RETENTION
+	bg.Go(audit.RunPruner)
+
+	// Remove expired refresh tokens
+	bg.Go(services.RunTokenCleanup)
+
+	// Delete data export archives once their retention has passed
+	bg.Go(services.RunExportSweeper)
 
 	port := os.Getenv("PORT")
 	if port == "" {
 		port = "8080"
 	}
 
-	// Initialize Gin router
-	router := gin.Default()
+	// Initialize Gin router (gin.New: the access log and panic recovery below replace gin's text logger)
+	router := gin.New()
+
+	// Only believe forwarded client IPs from configured proxies; lockouts and rate limits depend on them
+	if err := middleware.TrustProxies(router); err != nil {
+		slog.Error("invalid trusted proxy configuration", "error", err)
+		os.Exit(1)
+	}
 
 	// Apply middleware
+	router.Use(otelgin.Middleware(tracing.ServiceName))
+	router.Use(middleware.RequestID(), middleware.AccessLog(), middleware.Metrics(), middleware.Recovery())
 	router.Use(middleware.CORS())
+	router.Use(middleware.RequestInfo())
 
 	// Initialize handlers
 	authHandler := handlers.NewAuthHandler()
 	friendHandler := handlers.NewFriendHandler()
 	notificationHandler := handlers.NewNotificationHandler()
 	historyHandler := handlers.NewHistoryHandler()
+	statsHandler := handlers.NewStatsHandler()
+	userHandler := handlers.NewUserHandler()
+	exportHandler := handlers.NewExportHandler()
+	adminHandler := handlers.NewAdminHandler()
+	reportHandler := handlers.NewReportHandler()
+	healthHandler := handlers.NewHealthHandler()
+
+	// Health checks: /health is kept for existing monitors, /livez and /readyz are for the platform
+	router.GET("/health", healthHandler.Health)
+	router.HEAD("/health", healthHandler.Health)
+	router.GET("/livez", healthHandler.Livez)
+	router.HEAD("/livez", healthHandler.Livez)
+	router.GET("/readyz", healthHandler.Readyz)
+	router.HEAD("/readyz", healthHandler.Readyz)
+
+	// Metrics are never public: they're served on a separate port (METRICS_ADDR) or behind a bearer token (METRICS_TOKEN)
+	metrics.ObserveSessions(services.GetTokenStore().ActiveSessions)
+	var metricsServer *http.Server
+	if addr := os.Getenv("METRICS_ADDR"); addr != "" {
+		metricsServer = metrics.NewServer(addr)
+		go func() {
+			slog.Info("metrics server starting", "addr", addr)
+			if err := metricsServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				slog.Error("metrics server stopped", "error", err)
+			}
+		}()
+	} else if token := os.Getenv("METRICS_TOKEN"); token != "" {
+		router.GET("/metrics", middleware.MetricsToken(token), gin.WrapH(metrics.Handler()))
+	} else {
+		slog.Info("metrics disabled; set METRICS_ADDR or METRICS_TOKEN to expose them")
+	}
 
-	// Health check endpoint (supports both GET and HEAD requests)
-	healthHandler := func(c *gin.Context) {
-		c.JSON(200, gin.H{
-			"status":  "ok",
-			"message": "Return By Death API is running",
-		})
+	// Serve uploaded files when they're stored on local disk
+	if local, ok := storage.GetBlobStore().(*storage.LocalStore); ok {
+		router.Static(storage.LocalURLPrefix, local.Dir)
 	}
-	router.GET("/health", healthHandler)
-	router.HEAD("/health", healthHandler)
 
 	// API routes group
 	api := router.Group("/api")
 	{
-		// Auth routes (public)
+		// Auth routes
 		auth := api.Group("/auth")
 		{
-			auth.POST("/register", authHandler.Register)
-			auth.POST("/login", authHandler.Login)
+			// Public routes, limited per client IP
+			authPublic := auth.Group("")
+			authPublic.Use(middleware.RateLimit("auth"))
+			{
+				authPublic.POST("/register", authHandler.Register)
+				authPublic.POST("/login", authHandler.Login)
+				authPublic.POST("/reset-password", authHandler.ResetPassword)
+			}
 
-			// Protected routes
+			// Protected routes, limited per user like the other account routes
 			authProtected := auth.Group("")
-			authProtected.Use(middleware.AuthMiddleware())
+			authProtected.Use(middleware.AuthMiddleware(), middleware.RateLimit("users"))
 			{
 				authProtected.POST("/update-fcm-token", authHandler.UpdateFCMToken)
 				authProtected.POST("/refresh-token", authHandler.RefreshToken)
+				authProtected.POST("/change-password", authHandler.ChangePassword)
+				authProtected.POST("/recovery-codes", authHandler.RegenerateRecoveryCodes)
 			}
 		}
 
+		// User routes (protected)
+		users := api.Group("/users")
+		users.Use(middleware.AuthMiddleware(), middleware.RateLimit("users"))
+		{
+			users.GET("/me", userHandler.GetMe)
+			users.PATCH("/me", userHandler.UpdateProfile)
+			users.DELETE("/me", userHandler.DeleteAccount)
+			users.POST("/me/username", userHandler.ChangeUsername)
+			users.POST("/me/avatar", middleware.RateLimit("upload"), userHandler.UploadAvatar)
+			users.DELETE("/me/avatar", userHandler.DeleteAvatar)
+			users.POST("/me/export", middleware.RateLimit("export"), exportHandler.RequestExport)
+			users.GET("/me/export/:exportId", exportHandler.GetExport)
+			users.GET("/me/reports", reportHandler.GetMyReports)
+			users.GET("/me/activity", userHandler.GetActivity)
+			users.POST("/report", middleware.RateLimit("report"), reportHandler.ReportUser)
+			users.GET("/:userId", userHandler.GetProfile)
+		}
+
+		// Export downloads (public, authorized by the link token)
+		api.GET("/exports/:exportId/download", middleware.RateLimit("auth"), exportHandler.Download)
+
 		// Friends routes (protected)
 		friends := api.Group("/friends")
-		friends.Use(middleware.AuthMiddleware())
+		friends.Use(middleware.AuthMiddleware(), middleware.RateLimit("friends"))
 		{
 			friends.GET("", friendHandler.GetFriends)
 			friends.GET("/pending", friendHandler.GetPendingRequests)
-			friends.POST("/search", friendHandler.SearchUsers)
-			friends.POST("/request", friendHandler.SendFriendRequest)
+			friends.POST("/search", middleware.RateLimit("search"), friendHandler.SearchUsers)
+			friends.POST("/request", middleware.RateLimit("friend_request"), friendHandler.SendFriendRequest)
 			friends.POST("/accept", friendHandler.AcceptFriendRequest)
 			friends.POST("/reject", friendHandler.RejectFriendRequest)
 			friends.DELETE("/:friendUserId", friendHandler.RemoveFriend)
@@ -86,23 +202,115 @@ func main() {
 
 		// Notifications routes (protected)
 		notifications := api.Group("/notifications")
-		notifications.Use(middleware.AuthMiddleware())
+		notifications.Use(middleware.AuthMiddleware(), middleware.RateLimit("notifications"))
 		{
 			notifications.POST("/trigger", notificationHandler.TriggerNotification)
 			notifications.GET("/cooldown/:friendUserId", notificationHandler.CheckCooldown)
+			notifications.POST("/respond", notificationHandler.RespondToTrigger)
+			notifications.GET("/inbox", notificationHandler.GetInbox)
+			notifications.POST("/mark-read", notificationHandler.MarkRead)
+			notifications.POST("/:historyId/ack", notificationHandler.AcknowledgeTrigger)
 		}
 
 		// History routes (protected)
 		history := api.Group("/history")
-		history.Use(middleware.AuthMiddleware())
+		history.Use(middleware.AuthMiddleware(), middleware.RateLimit("history"))
 		{
 			history.GET("/:friendUserId", historyHandler.GetHistory)
+			history.DELETE("/:friendUserId", historyHandler.DeleteHistory)
+			history.DELETE("/:friendUserId/:historyId", historyHandler.DeleteHistory)
 		}
+
+		// Moderation and admin routes (protected, role-restricted)
+		admin := api.Group("/admin")
+		admin.Use(middleware.AuthMiddleware(), middleware.RequireRole(models.RoleModerator), middleware.RateLimit("admin"))
+		{
+			admin.GET("/users/:userId", adminHandler.GetUser)
+			admin.GET("/users/:userId/friendships", adminHandler.GetFriendships)
+			admin.POST("/users/:userId/suspend", adminHandler.SuspendUser)
+			admin.POST("/users/:userId/unsuspend", adminHandler.UnsuspendUser)
+			admin.POST("/users/:userId/revoke-sessions", adminHandler.RevokeSessions)
+			admin.POST("/users/:userId/clear-cooldowns", adminHandler.ClearCooldowns)
+			admin.GET("/reports", reportHandler.GetQueue)
+			admin.GET("/reports/:reportId", reportHandler.GetReport)
+			admin.POST("/reports/:reportId/claim", reportHandler.ClaimReport)
+			admin.POST("/reports/:reportId/resolve", reportHandler.ResolveReport)
+
+			// Admin only
+			admin.POST("/users/:userId/ban", middleware.RequireRole(models.RoleAdmin), adminHandler.BanUser)
+			admin.PUT("/users/:userId/role", middleware.RequireRole(models.RoleAdmin), adminHandler.SetRole)
+			admin.GET("/audit-log", middleware.RequireRole(models.RoleAdmin), adminHandler.GetAuditLog)
+		}
+
+		// Stats routes (protected)
+		stats := api.Group("/stats")
+		stats.Use(middleware.AuthMiddleware(), middleware.RateLimit("stats"))
+		{
+			stats.GET("", statsHandler.GetStats)
+			stats.GET("/:friendUserId", statsHandler.GetFriendStats)
+		}
+	}
+
+	// Start server. Bind first so a taken port fails startup instead of briefly reporting ready;
+	// from here on /readyz is decided by the registered checks.
+	srv := newServer(":"+port, router)
+	listener, err := net.Listen("tcp", srv.Addr)
+	if err != nil {
+		slog.Error("failed to listen", "addr", srv.Addr, "error", err)
+		os.Exit(1)
+	}
+	serveErr := make(chan error, 1)
+	go func() {
+		slog.Info("server starting", "port", port)
+		serveErr <- srv.Serve(listener)
+	}()
+	health.SetState(health.StateReady)
+
+	stop, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	exitCode := 0
+	select {
+	case err := <-serveErr:
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			slog.Error("server stopped", "error", err)
+			exitCode = 1
+		}
+	case <-stop.Done():
+		slog.Info("shutdown signal received")
+	}
+
+	// Fail readiness first so the load balancer stops routing here, then drain in-flight
+	// requests, stop workers, wait for detached export builds and flush spans, all within SHUTDOWN_TIMEOUT
+	health.SetState(health.StateShuttingDown)
+	timeout := envDuration("SHUTDOWN_TIMEOUT", 25*time.Second)
+	ctx, cancelShutdown := context.WithTimeout(context.Background(), timeout)
+	defer cancelShutdown()
+
+	if err := srv.Shutdown(ctx); err != nil {
+		slog.Error("failed to drain HTTP requests", "error", err)
+		exitCode = 1
+	}
+	if metricsServer != nil {
+		if err := metricsServer.Shutdown(ctx); err != nil {
+			slog.Error("failed to stop metrics server", "error", err)
+		}
+	}
+	if err := bg.Stop(ctx); err != nil {
+		slog.Error("background workers did not stop in time", "error", err)
+		exitCode = 1
+	}
+	if err := services.FlushPending(ctx); err != nil {
+		slog.Error("pending work did not finish in time", "error", err)
+		exitCode = 1
+	}
+	if err := shutdownTracing(ctx); err != nil {
+		slog.Error("failed to flush traces", "error", err)
 	}
+	config.CloseFirebase()
 
-	// Start server
-	log.Printf("🚀 Server starting on port %s", port)
-	if err := router.Run(":" + port); err != nil {
-		log.Fatalf("Failed to start server: %v", err)
+	slog.Info("server stopped", "timeout", timeout)
+	if exitCode != 0 {
+		os.Exit(exitCode)
 	}
 }
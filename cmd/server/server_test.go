@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestShutdownWaitsForInFlightRequests(t *testing.T) {
+	started := make(chan struct{})
+	slow := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		time.Sleep(300 * time.Millisecond)
+		io.WriteString(w, "done")
+	})
+
+	// Serve newServer (with its timeouts) on the httptest listener
+	ts := httptest.NewUnstartedServer(nil)
+	defer ts.Close()
+	srv := newServer(ts.Listener.Addr().String(), slow)
+	serveErr := make(chan error, 1)
+	go func() { serveErr <- srv.Serve(ts.Listener) }()
+
+	type result struct {
+		status int
+		body   string
+		err    error
+	}
+	response := make(chan result, 1)
+	go func() {
+		resp, err := http.Get("http://" + ts.Listener.Addr().String())
+		if err != nil {
+			response <- result{err: err}
+			return
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		response <- result{status: resp.StatusCode, body: string(body), err: err}
+	}()
+
+	select {
+	case <-started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("request never reached the handler")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	shutdownStart := time.Now()
+	if err := srv.Shutdown(ctx); err != nil {
+		t.Fatalf("shutdown: %v", err)
+	}
+	if waited := time.Since(shutdownStart); waited < 100*time.Millisecond {
+		t.Fatalf("shutdown returned after %s without waiting for the in-flight request", waited)
+	}
+
+	// The request that was running when shutdown began still completes
+	got := <-response
+	if got.err != nil {
+		t.Fatalf("in-flight request failed: %v", got.err)
+	}
+	if got.status != http.StatusOK || got.body != "done" {
+		t.Fatalf("in-flight request got %d %q", got.status, got.body)
+	}
+
+	if err := <-serveErr; !errors.Is(err, http.ErrServerClosed) {
+		t.Fatalf("Serve returned %v, expected http.ErrServerClosed", err)
+	}
+
+	// New requests are refused once the server has shut down
+	if resp, err := http.Get("http://" + ts.Listener.Addr().String()); err == nil {
+		resp.Body.Close()
+		t.Fatal("request after shutdown succeeded")
+	}
+}
+
+func TestWorkersStopCancelsAndWaits(t *testing.T) {
+	bg := newWorkers()
+
+	var stopped atomic.Int32
+	for i := 0; i < 3; i++ {
+		bg.Go(func(ctx context.Context) {
+			<-ctx.Done()
+			// Simulate finishing the current run before returning
+			time.Sleep(50 * time.Millisecond)
+			stopped.Add(1)
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := bg.Stop(ctx); err != nil {
+		t.Fatalf("stop: %v", err)
+	}
+	if n := stopped.Load(); n != 3 {
+		t.Fatalf("Stop returned with %d of 3 workers finished", n)
+	}
+}
+
+func TestWorkersStopGivesUpAtDeadline(t *testing.T) {
+	bg := newWorkers()
+
+	release := make(chan struct{})
+	defer close(release)
+	bg.Go(func(ctx context.Context) {
+		<-release // ignores cancellation
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := bg.Stop(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the deadline error from a stuck worker, got %v", err)
+	}
+}
+
+func TestNewServerTimeouts(t *testing.T) {
+	t.Setenv("HTTP_READ_TIMEOUT", "7s")
+	t.Setenv("HTTP_WRITE_TIMEOUT", "not-a-duration")
+
+	srv := newServer(":0", http.NotFoundHandler())
+	if srv.ReadTimeout != 7*time.Second {
+		t.Errorf("ReadTimeout = %s, expected 7s from the environment", srv.ReadTimeout)
+	}
+	if srv.WriteTimeout != 60*time.Second {
+		t.Errorf("WriteTimeout = %s, expected the 60s default for an invalid value", srv.WriteTimeout)
+	}
+	if srv.ReadHeaderTimeout == 0 || srv.IdleTimeout == 0 {
+		t.Error("header and idle timeouts must always be set")
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/joho/godotenv"
+	"github.com/yourusername/rbd-service/internal/config"
+	"github.com/yourusername/rbd-service/internal/logging"
+	"github.com/yourusername/rbd-service/internal/models"
+	"github.com/yourusername/rbd-service/internal/repository"
+)
+
+// Usage: setrole <username> <user|moderator|admin>
+// Used to appoint the first admin; after that roles can be managed through /api/admin.
+func main() {
+	if len(os.Args) != 3 {
+		fmt.Println("Usage: setrole <username> <user|moderator|admin>")
+		os.Exit(1)
+	}
+	username, role := os.Args[1], models.Role(os.Args[2])
+
+	// Load environment variables (before the logger, so LOG_LEVEL can come from .env)
+	envErr := godotenv.Load()
+	logging.Init()
+	if envErr != nil {
+		slog.Info("no .env file found, using system environment variables")
+	}
+
+	if role != models.RoleUser && role != models.RoleModerator && role != models.RoleAdmin {
+		slog.Error("unknown role", "role", role)
+		os.Exit(1)
+	}
+
+	// Initialize Firebase
+	if err := config.InitFirebase(); err != nil {
+		slog.Error("failed to initialize Firebase", "error", err)
+		os.Exit(1)
+	}
+
+	err := setRole(context.Background(), username, role)
+	config.CloseFirebase()
+	if err != nil {
+		slog.Error("failed to set role", "username", username, "role", role, "error", err)
+		os.Exit(1)
+	}
+}
+
+// setRole changes the user's role and records it in the audit log
+func setRole(ctx context.Context, username string, role models.Role) error {
+	userRepo := repository.NewUserRepository()
+
+	user, err := userRepo.GetUserByUsername(ctx, username)
+	if err != nil {
+		return fmt.Errorf("user not found: %w", err)
+	}
+	if err := userRepo.SetRole(ctx, user.UserID, role); err != nil {
+		return err
+	}
+
+	err = repository.NewAuditRepository().Record(ctx, &models.AuditEntry{
+		ActorID:      "cli",
+		Action:       models.AuditAdminSetRole,
+		TargetUserID: user.UserID,
+		Details: map[string]interface{}{
+			"previousRole": string(user.EffectiveRole()),
+			"role":         string(role),
+		},
+	})
+	if err != nil {
+		slog.Warn("failed to write audit entry", "user_id", user.UserID, "error", err)
+	}
+
+	slog.Info("role updated", "username", user.Username, "user_id", user.UserID, "role", role)
+	return nil
+}
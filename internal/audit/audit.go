@@ -0,0 +1,106 @@
+package audit
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/yourusername/rbd-service/internal/health"
+	"github.com/yourusername/rbd-service/internal/metrics"
+	"github.com/yourusername/rbd-service/internal/models"
+	"github.com/yourusername/rbd-service/internal/repository"
+)
+
+// DefaultRetention is how long entries are kept unless AUDIT_RETENTION says otherwise
+const DefaultRetention = 365 * 24 * time.Hour
+
+type requestInfoKey struct{}
+
+// RequestInfo is the client information attached to every entry recorded while serving a request
+type RequestInfo struct {
+	IP        string
+	UserAgent string
+}
+
+// WithRequestInfo returns a context carrying the client's IP and user agent
+func WithRequestInfo(ctx context.Context, info RequestInfo) context.Context {
+	return context.WithValue(ctx, requestInfoKey{}, info)
+}
+
+// RequestInfoFrom returns the client information stored in ctx (empty outside a request)
+func RequestInfoFrom(ctx context.Context) RequestInfo {
+	info, _ := ctx.Value(requestInfoKey{}).(RequestInfo)
+	return info
+}
+
+// Record appends an entry to the audit log, filling in the client's IP and user agent from ctx.
+// Failures are logged rather than returned so an action that already happened isn't reported as failed.
+func Record(ctx context.Context, entry *models.AuditEntry) {
+	info := RequestInfoFrom(ctx)
+	if entry.IP == "" {
+		entry.IP = info.IP
+	}
+	if entry.UserAgent == "" {
+		entry.UserAgent = info.UserAgent
+	}
+
+	if err := repository.NewAuditRepository().Record(ctx, entry); err != nil {
+		slog.ErrorContext(ctx, "failed to write audit entry", "action", entry.Action, "actor_id", entry.ActorID, "target_id", entry.TargetUserID, "error", err)
+	}
+}
+
+// UserAction records something a user did themselves, visible in their own activity.
+// targetUserID is the other user involved, if any.
+func UserAction(ctx context.Context, userID, action, targetUserID string, before, after map[string]interface{}) {
+	Record(ctx, &models.AuditEntry{
+		ActorID:      userID,
+		ActorRole:    models.RoleUser,
+		Action:       action,
+		TargetUserID: targetUserID,
+		Before:       before,
+		After:        after,
+		VisibleTo:    []string{userID},
+	})
+}
+
+// Retention returns how long audit entries are kept (AUDIT_RETENTION, e.g. "8760h")
+func Retention() time.Duration {
+	if value := os.Getenv("AUDIT_RETENTION"); value != "" {
+		if d, err := time.ParseDuration(value); err == nil && d > 0 {
+			return d
+		}
+		slog.Warn("invalid AUDIT_RETENTION, using default", "value", value)
+	}
+	return DefaultRetention
+}
+
+// Prune deletes entries older than the retention window
+func Prune(ctx context.Context) (int, error) {
+	return repository.NewAuditRepository().DeleteOlderThan(ctx, time.Now().Add(-Retention()))
+}
+
+// RunPruner prunes expired entries once an hour until ctx is cancelled
+func RunPruner(ctx context.Context) {
+	health.WatchWorker("audit_prune", 3*time.Hour)
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	for {
+		start := time.Now()
+		deleted, err := Prune(ctx)
+		metrics.ObserveJob("audit_prune", start, err)
+		health.Beat("audit_prune")
+		if err != nil {
+			slog.ErrorContext(ctx, "audit log pruning failed", "error", err)
+		} else if deleted > 0 {
+			slog.InfoContext(ctx, "pruned expired audit entries", "count", deleted)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -2,7 +2,7 @@ package config
 
 import (
 	"context"
-	"log"
+	"log/slog"
 	"os"
 
 	firebase "firebase.google.com/go"
@@ -25,7 +25,7 @@ func InitFirebase() error {
 
 	// Check if credentials are provided as environment variable (for Render/cloud deployment)
 	if credsJSON := os.Getenv("FIREBASE_CREDENTIALS"); credsJSON != "" {
-		log.Println("📝 Using Firebase credentials from environment variable")
+		slog.Info("using Firebase credentials from environment variable")
 		opt = option.WithCredentialsJSON([]byte(credsJSON))
 	} else {
 		// Fall back to credentials file (for local development)
@@ -36,41 +36,40 @@ func InitFirebase() error {
 
 		// Check if credentials file exists
 		if _, err := os.Stat(credentialsPath); os.IsNotExist(err) {
-			log.Printf("⚠️  Firebase credentials not found at %s", credentialsPath)
-			log.Println("📝 Please set FIREBASE_CREDENTIALS env var or place serviceAccountKey.json")
+			slog.Error("Firebase credentials not found; set FIREBASE_CREDENTIALS or place serviceAccountKey.json", "path", credentialsPath)
 			return err
 		}
 
-		log.Printf("📝 Using Firebase credentials from file: %s", credentialsPath)
+		slog.Info("using Firebase credentials from file", "path", credentialsPath)
 		opt = option.WithCredentialsFile(credentialsPath)
 	}
 
 	app, err := firebase.NewApp(ctx, nil, opt)
 	if err != nil {
-		log.Printf("Error initializing Firebase app: %v", err)
+		slog.Error("failed to initialize Firebase app", "error", err)
 		return err
 	}
 
 	FirebaseApp = app
-	log.Println("✅ Firebase app initialized")
+	slog.Info("Firebase app initialized")
 
 	// Initialize Firestore client
 	firestoreClient, err := app.Firestore(ctx)
 	if err != nil {
-		log.Printf("Error initializing Firestore: %v", err)
+		slog.Error("failed to initialize Firestore", "error", err)
 		return err
 	}
 	FirestoreClient = firestoreClient
-	log.Println("✅ Firestore client initialized")
+	slog.Info("Firestore client initialized")
 
 	// Initialize Auth client
 	authClient, err := app.Auth(ctx)
 	if err != nil {
-		log.Printf("Error initializing Auth: %v", err)
+		slog.Error("failed to initialize Firebase Auth", "error", err)
 		return err
 	}
 	AuthClient = authClient
-	log.Println("✅ Firebase Auth client initialized")
+	slog.Info("Firebase Auth client initialized")
 
 	return nil
 }
@@ -79,6 +78,6 @@ func InitFirebase() error {
 func CloseFirebase() {
 	if FirestoreClient != nil {
 		FirestoreClient.Close()
-		log.Println("🔌 Firestore connection closed")
+		slog.Info("Firestore connection closed")
 	}
 }
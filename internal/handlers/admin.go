@@ -0,0 +1,180 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourusername/rbd-service/internal/models"
+	"github.com/yourusername/rbd-service/internal/services"
+)
+
+type AdminHandler struct {
+	adminService *services.AdminService
+}
+
+func NewAdminHandler() *AdminHandler {
+	return &AdminHandler{
+		adminService: services.NewAdminService(),
+	}
+}
+
+// auditActor identifies the staff member making the request (set by AuthMiddleware and RequireRole)
+func auditActor(c *gin.Context) *models.AuditActor {
+	return &models.AuditActor{
+		UserID: c.GetString("userID"),
+		Role:   models.Role(c.GetString("role")),
+	}
+}
+
+// respondAdminError maps admin service errors to status codes
+func respondAdminError(c *gin.Context, err error) {
+	switch err.Error() {
+	case "user not found":
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case "insufficient role", "cannot change your own role":
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case "user is not suspended", "user is banned", "suspension end must be in the future", "invalid cursor":
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// GetUser looks up a user by ID or username
+func (h *AdminHandler) GetUser(c *gin.Context) {
+	user, err := h.adminService.LookupUser(c.Request.Context(), auditActor(c), c.Param("userId"))
+	if err != nil {
+		respondAdminError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// GetFriendships lists a user's friendships and requests
+func (h *AdminHandler) GetFriendships(c *gin.Context) {
+	friendships, err := h.adminService.GetFriendships(c.Request.Context(), auditActor(c), c.Param("userId"))
+	if err != nil {
+		respondAdminError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"friendships": friendships})
+}
+
+// SuspendUser suspends a user's account
+func (h *AdminHandler) SuspendUser(c *gin.Context) {
+	var req models.SuspendUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.adminService.SuspendUser(c.Request.Context(), auditActor(c), c.Param("userId"), req.Reason, req.Until); err != nil {
+		respondAdminError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "user suspended"})
+}
+
+// BanUser bans a user's account
+func (h *AdminHandler) BanUser(c *gin.Context) {
+	var req models.BanUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.adminService.BanUser(c.Request.Context(), auditActor(c), c.Param("userId"), req.Reason); err != nil {
+		respondAdminError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "user banned"})
+}
+
+// UnsuspendUser lifts a user's suspension or ban
+func (h *AdminHandler) UnsuspendUser(c *gin.Context) {
+	var req models.AdminActionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.adminService.UnsuspendUser(c.Request.Context(), auditActor(c), c.Param("userId"), req.Reason); err != nil {
+		respondAdminError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "user unsuspended"})
+}
+
+// RevokeSessions signs a user out everywhere
+func (h *AdminHandler) RevokeSessions(c *gin.Context) {
+	var req models.AdminActionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	revoked, err := h.adminService.RevokeSessions(c.Request.Context(), auditActor(c), c.Param("userId"), req.Reason)
+	if err != nil {
+		respondAdminError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"sessionsRevoked": revoked})
+}
+
+// ClearCooldowns removes all cooldowns to and from a user
+func (h *AdminHandler) ClearCooldowns(c *gin.Context) {
+	var req models.AdminActionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	cleared, err := h.adminService.ClearCooldowns(c.Request.Context(), auditActor(c), c.Param("userId"), req.Reason)
+	if err != nil {
+		respondAdminError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"cooldownsCleared": cleared})
+}
+
+// SetRole changes a user's role
+func (h *AdminHandler) SetRole(c *gin.Context) {
+	var req models.SetRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.adminService.SetRole(c.Request.Context(), auditActor(c), c.Param("userId"), req.Role, req.Reason); err != nil {
+		respondAdminError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "role updated"})
+}
+
+// GetAuditLog lists audit entries, optionally filtered by ?actorId= and ?targetUserId=
+func (h *AdminHandler) GetAuditLog(c *gin.Context) {
+	limit := 50
+	if limitStr := c.Query("limit"); limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 && l <= 200 {
+			limit = l
+		}
+	}
+
+	resp, err := h.adminService.GetAuditLog(c.Request.Context(), c.Query("actorId"), c.Query("targetUserId"), c.Query("cursor"), limit)
+	if err != nil {
+		respondAdminError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
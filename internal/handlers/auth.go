@@ -1,7 +1,11 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/yourusername/rbd-service/internal/models"
@@ -26,8 +30,11 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		return
 	}
 
-	resp, err := h.authService.Register(c.Request.Context(), &req)
+	resp, err := h.authService.Register(c.Request.Context(), &req, c.ClientIP())
 	if err != nil {
+		if respondLockout(c, err) {
+			return
+		}
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
@@ -43,8 +50,20 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
-	resp, err := h.authService.Login(c.Request.Context(), &req)
+	resp, err := h.authService.Login(c.Request.Context(), &req, c.ClientIP())
 	if err != nil {
+		if respondLockout(c, err) {
+			return
+		}
+		var restriction *models.AccountRestriction
+		if errors.As(err, &restriction) {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error":  restriction.Error(),
+				"reason": restriction.Reason,
+				"until":  restriction.Until,
+			})
+			return
+		}
 		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 		return
 	}
@@ -52,6 +71,31 @@ func (h *AuthHandler) Login(c *gin.Context) {
 	c.JSON(http.StatusOK, resp)
 }
 
+// respondLockout writes a 429 with Retry-After for limiter errors ("too_many_attempts:<s>", "account_locked:<s>").
+// Returns false if err isn't a limiter error.
+func respondLockout(c *gin.Context, err error) bool {
+	code, secs, ok := strings.Cut(err.Error(), ":")
+	if !ok || (code != "too_many_attempts" && code != "account_locked") {
+		return false
+	}
+	retryAfter, convErr := strconv.Atoi(secs)
+	if convErr != nil {
+		return false
+	}
+
+	c.Header("Retry-After", secs)
+	body := gin.H{
+		"error":      code,
+		"retryAfter": retryAfter,
+	}
+	if code == "account_locked" {
+		body["message"] = "Too many failed login attempts. This account is temporarily locked."
+		body["lockedUntil"] = time.Now().Add(time.Duration(retryAfter) * time.Second).UTC().Format(time.RFC3339)
+	}
+	c.JSON(http.StatusTooManyRequests, body)
+	return true
+}
+
 // UpdateFCMToken handles FCM token updates
 func (h *AuthHandler) UpdateFCMToken(c *gin.Context) {
 	var req models.UpdateFCMTokenRequest
@@ -82,16 +126,10 @@ func (h *AuthHandler) RefreshToken(c *gin.Context) {
 		return
 	}
 
-	// Get the current token from header
-	token := c.GetHeader("Authorization")
-	if token == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "no token provided"})
-		return
-	}
-
-	// Refresh the token expiration
-	if !services.GetTokenStore().RefreshToken(token) {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid or expired token"})
+	// The auth middleware stores the bare token (without the "Bearer " prefix)
+	token := c.GetString("token")
+	if err := h.authService.RefreshToken(c.Request.Context(), userID, token); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 		return
 	}
 
@@ -100,3 +138,79 @@ func (h *AuthHandler) RefreshToken(c *gin.Context) {
 		"message": "token refreshed successfully",
 	})
 }
+
+// ChangePassword changes the current user's password and signs out their other sessions
+func (h *AuthHandler) ChangePassword(c *gin.Context) {
+	userID := c.GetString("userID")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	var req models.ChangePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.authService.ChangePassword(c.Request.Context(), userID, c.GetString("token"), &req); err != nil {
+		if err.Error() == "current password is incorrect" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+// ResetPassword sets a new password using a one-time recovery code
+func (h *AuthHandler) ResetPassword(c *gin.Context) {
+	var req models.ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.authService.ResetPassword(c.Request.Context(), &req); err != nil {
+		if respondLockout(c, err) {
+			return
+		}
+		if err.Error() == "invalid username or recovery code" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+// RegenerateRecoveryCodes replaces the current user's recovery codes
+func (h *AuthHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	userID := c.GetString("userID")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	var req models.RegenerateRecoveryCodesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	codes, err := h.authService.RegenerateRecoveryCodes(c.Request.Context(), userID, req.Password)
+	if err != nil {
+		if err.Error() == "current password is incorrect" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"recoveryCodes": codes})
+}
@@ -66,13 +66,22 @@ func (h *FriendHandler) SearchUsers(c *gin.Context) {
 		return
 	}
 
-	users, err := h.friendService.SearchUsers(c.Request.Context(), userID, req.Username)
+	limit := req.Limit
+	if limit == 0 {
+		limit = 20
+	}
+
+	results, err := h.friendService.SearchUsers(c.Request.Context(), userID, req.Username, req.Cursor, limit)
 	if err != nil {
+		if err.Error() == "invalid cursor" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"users": users})
+	c.JSON(http.StatusOK, results)
 }
 
 // SendFriendRequest sends a friend request
@@ -156,7 +165,10 @@ func (h *FriendHandler) RemoveFriend(c *gin.Context) {
 		return
 	}
 
-	if err := h.friendService.RemoveFriend(c.Request.Context(), userID, friendUserID); err != nil {
+	// ?purgeHistory=true also deletes the shared history for both users
+	purgeHistory := c.Query("purgeHistory") == "true"
+
+	if err := h.friendService.RemoveFriend(c.Request.Context(), userID, friendUserID, purgeHistory); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourusername/rbd-service/internal/health"
+)
+
+type HealthHandler struct{}
+
+func NewHealthHandler() *HealthHandler {
+	return &HealthHandler{}
+}
+
+// Health is the original always-ok check, kept for existing monitors
+func (h *HealthHandler) Health(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "ok",
+		"message": "Return By Death API is running",
+	})
+}
+
+// Livez reports whether the process is alive. It never touches dependencies,
+// so a datastore outage doesn't get healthy instances restarted.
+func (h *HealthHandler) Livez(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"status": health.StatusOK,
+		"state":  health.CurrentState(),
+	})
+}
+
+// Readyz probes every dependency and returns 503 with the breakdown if any fails,
+// or while the service is still starting or shutting down
+func (h *HealthHandler) Readyz(c *gin.Context) {
+	report := health.Check(c.Request.Context())
+	if !report.Ready() {
+		c.JSON(http.StatusServiceUnavailable, report)
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
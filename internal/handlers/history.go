@@ -5,16 +5,16 @@ import (
 	"strconv"
 
 	"github.com/gin-gonic/gin"
-	"github.com/yourusername/rbd-service/internal/repository"
+	"github.com/yourusername/rbd-service/internal/services"
 )
 
 type HistoryHandler struct {
-	historyRepo *repository.HistoryRepository
+	historyService *services.HistoryService
 }
 
 func NewHistoryHandler() *HistoryHandler {
 	return &HistoryHandler{
-		historyRepo: repository.NewHistoryRepository(),
+		historyService: services.NewHistoryService(),
 	}
 }
 
@@ -33,27 +33,59 @@ func (h *HistoryHandler) GetHistory(c *gin.Context) {
 	}
 
 	// Parse pagination params
-	page := 1
 	limit := 50
-	if pageStr := c.Query("page"); pageStr != "" {
-		if p, err := strconv.Atoi(pageStr); err == nil && p > 0 {
-			page = p
-		}
-	}
 	if limitStr := c.Query("limit"); limitStr != "" {
 		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 && l <= 100 {
 			limit = l
 		}
 	}
 
-	history, total, err := h.historyRepo.GetHistoryBetweenUsers(c.Request.Context(), userID, friendUserID, page, limit)
+	history, err := h.historyService.GetHistory(c.Request.Context(), userID, friendUserID, c.Query("cursor"), limit)
+	if err != nil {
+		switch err.Error() {
+		case "users are not friends":
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case "invalid cursor":
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, history)
+}
+
+// DeleteHistory removes history with a friend from the current user's side.
+// Without a historyId every record with the friend is removed.
+func (h *HistoryHandler) DeleteHistory(c *gin.Context) {
+	userID := c.GetString("userID")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	friendUserID := c.Param("friendUserId")
+	if friendUserID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "friendUserId is required"})
+		return
+	}
+
+	deleted, err := h.historyService.DeleteHistory(c.Request.Context(), userID, friendUserID, c.Param("historyId"))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		switch err.Error() {
+		case "users are not friends":
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case "history not found":
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"history": history,
-		"total":   total,
+		"success": true,
+		"deleted": deleted,
 	})
 }
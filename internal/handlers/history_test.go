@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourusername/rbd-service/internal/models"
+	"github.com/yourusername/rbd-service/internal/repository"
+	"github.com/yourusername/rbd-service/internal/testutil"
+)
+
+// Runs against the Firestore emulator; skipped when FIRESTORE_EMULATOR_HOST is unset
+func TestHistoryAccess(t *testing.T) {
+	client := testutil.Firestore(t)
+	ctx := context.Background()
+	gin.SetMode(gin.TestMode)
+
+	alice, bob, carol, dave := testutil.ID("alice"), testutil.ID("bob"), testutil.ID("carol"), testutil.ID("dave")
+
+	// Alice and Bob are friends; Alice and Dave were friends once and still share history
+	if _, _, err := client.Collection("friends").Add(ctx, models.Friendship{
+		User1ID:     alice,
+		User2ID:     bob,
+		Status:      models.StatusAccepted,
+		RequestedAt: time.Now(),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	historyRepo := repository.NewHistoryRepository()
+	aliceBob, err := historyRepo.CreateHistory(ctx, alice, bob, "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	aliceDave, err := historyRepo.CreateHistory(ctx, dave, alice, "dave")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	handler := NewHistoryHandler()
+	router := gin.New()
+	history := router.Group("/api/history", func(c *gin.Context) {
+		c.Set("userID", c.GetHeader("X-Test-User"))
+	})
+	history.GET("/:friendUserId", handler.GetHistory)
+	history.DELETE("/:friendUserId", handler.DeleteHistory)
+	history.DELETE("/:friendUserId/:historyId", handler.DeleteHistory)
+
+	do := func(method, path, userID string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("X-Test-User", userID)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		userID string
+		status int
+	}{
+		{"unrelated user reads history", http.MethodGet, "/api/history/" + bob, carol, http.StatusForbidden},
+		{"unrelated user deletes a record of another pair", http.MethodDelete, "/api/history/" + alice + "/" + aliceBob, carol, http.StatusForbidden},
+		{"unrelated user deletes all history", http.MethodDelete, "/api/history/" + bob, carol, http.StatusForbidden},
+		{"friend deletes a record of another pair", http.MethodDelete, "/api/history/" + alice + "/" + aliceDave, bob, http.StatusNotFound},
+		{"friend reads history", http.MethodGet, "/api/history/" + bob, alice, http.StatusOK},
+		{"former friend reads shared history", http.MethodGet, "/api/history/" + alice, dave, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := do(tt.method, tt.path, tt.userID)
+			if rec.Code != tt.status {
+				t.Fatalf("expected %d, got %d: %s", tt.status, rec.Code, rec.Body.String())
+			}
+		})
+	}
+
+	// The former friend sees the record they share, and nothing was deleted by the rejected calls
+	rec := do(http.MethodGet, "/api/history/"+alice, dave)
+	var page models.HistoryResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &page); err != nil {
+		t.Fatal(err)
+	}
+	if len(page.History) != 1 || page.History[0].HistoryID != aliceDave {
+		t.Fatalf("expected the shared record %s, got %+v", aliceDave, page.History)
+	}
+
+	rec = do(http.MethodGet, "/api/history/"+bob, alice)
+	page = models.HistoryResponse{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &page); err != nil {
+		t.Fatal(err)
+	}
+	if len(page.History) != 1 || page.History[0].HistoryID != aliceBob {
+		t.Fatalf("expected %s to survive the rejected deletes, got %+v", aliceBob, page.History)
+	}
+}
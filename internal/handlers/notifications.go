@@ -1,7 +1,10 @@
 package handlers
 
 import (
+	"errors"
+	"io"
 	"net/http"
+	"strconv"
 	"strings"
 
 	"github.com/gin-gonic/gin"
@@ -50,6 +53,12 @@ func (h *NotificationHandler) TriggerNotification(c *gin.Context) {
 			return
 		}
 
+		var restriction *models.AccountRestriction
+		if errors.As(err, &restriction) {
+			c.JSON(http.StatusForbidden, gin.H{"error": restriction.Error()})
+			return
+		}
+
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
@@ -79,3 +88,128 @@ func (h *NotificationHandler) CheckCooldown(c *gin.Context) {
 
 	c.JSON(http.StatusOK, response)
 }
+
+// AcknowledgeTrigger records delivery or open state for a received trigger
+func (h *NotificationHandler) AcknowledgeTrigger(c *gin.Context) {
+	userID := c.GetString("userID")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	historyID := c.Param("historyId")
+	if historyID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "historyId is required"})
+		return
+	}
+
+	var req models.AckTriggerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	history, err := h.notificationService.AcknowledgeTrigger(c.Request.Context(), userID, historyID, req.State)
+	if err != nil {
+		if err.Error() == "trigger not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, history)
+}
+
+// RespondToTrigger sends a reply back to the sender of a received trigger
+func (h *NotificationHandler) RespondToTrigger(c *gin.Context) {
+	userID := c.GetString("userID")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	var req models.RespondTriggerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response, err := h.notificationService.RespondToTrigger(c.Request.Context(), userID, req.HistoryID, req.Response)
+	if err != nil {
+		if strings.HasPrefix(err.Error(), "cooldown_active:") {
+			parts := strings.SplitN(err.Error(), ":", 2)
+			c.JSON(http.StatusTooManyRequests, gin.H{
+				"error":       "cooldown_active",
+				"availableAt": parts[1],
+			})
+			return
+		}
+
+		if err.Error() == "trigger not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetInbox returns every notification the current user has received, newest first
+func (h *NotificationHandler) GetInbox(c *gin.Context) {
+	userID := c.GetString("userID")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	limit := 50
+	if limitStr := c.Query("limit"); limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 && l <= 100 {
+			limit = l
+		}
+	}
+
+	inbox, err := h.notificationService.GetInbox(c.Request.Context(), userID, c.Query("cursor"), limit)
+	if err != nil {
+		if err.Error() == "invalid cursor" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, inbox)
+}
+
+// MarkRead marks inbox entries as read
+func (h *NotificationHandler) MarkRead(c *gin.Context) {
+	userID := c.GetString("userID")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	// An empty body is the same as an empty list: mark everything as read
+	var req models.MarkReadRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	unread, err := h.notificationService.MarkRead(c.Request.Context(), userID, req.HistoryIDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":     true,
+		"unreadCount": unread,
+	})
+}
@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourusername/rbd-service/internal/models"
+	"github.com/yourusername/rbd-service/internal/services"
+)
+
+type ReportHandler struct {
+	moderationService *services.ModerationService
+}
+
+func NewReportHandler() *ReportHandler {
+	return &ReportHandler{
+		moderationService: services.NewModerationService(),
+	}
+}
+
+// respondReportError maps moderation service errors to status codes
+func respondReportError(c *gin.Context, err error) {
+	switch err.Error() {
+	case "user not found", "report not found":
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case "already reported", "report already claimed", "report already resolved", "report must be claimed first":
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case "insufficient role":
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case "cannot report yourself", "invalid history reference", "invalid status", "invalid cursor", "user is banned":
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// pageLimit reads ?limit= (default 20, max 100)
+func pageLimit(c *gin.Context) int {
+	limit := 20
+	if limitStr := c.Query("limit"); limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 && l <= 100 {
+			limit = l
+		}
+	}
+	return limit
+}
+
+// ReportUser files a report about another user
+func (h *ReportHandler) ReportUser(c *gin.Context) {
+	userID := c.GetString("userID")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	var req models.ReportUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	report, err := h.moderationService.ReportUser(c.Request.Context(), userID, &req)
+	if err != nil {
+		respondReportError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, report)
+}
+
+// GetMyReports lists the current user's reports and their status
+func (h *ReportHandler) GetMyReports(c *gin.Context) {
+	userID := c.GetString("userID")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	reports, nextCursor, err := h.moderationService.GetMyReports(c.Request.Context(), userID, c.Query("cursor"), pageLimit(c))
+	if err != nil {
+		respondReportError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"reports":    reports,
+		"nextCursor": nextCursor,
+	})
+}
+
+// GetQueue lists reports by status (?status=open by default), oldest first
+func (h *ReportHandler) GetQueue(c *gin.Context) {
+	status := models.ReportStatus(c.DefaultQuery("status", string(models.ReportOpen)))
+
+	queue, err := h.moderationService.GetQueue(c.Request.Context(), status, c.Query("cursor"), pageLimit(c))
+	if err != nil {
+		respondReportError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, queue)
+}
+
+// GetReport returns a single report with its snapshot
+func (h *ReportHandler) GetReport(c *gin.Context) {
+	report, err := h.moderationService.GetReport(c.Request.Context(), c.Param("reportId"))
+	if err != nil {
+		respondReportError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// ClaimReport assigns a report to the current moderator
+func (h *ReportHandler) ClaimReport(c *gin.Context) {
+	report, err := h.moderationService.ClaimReport(c.Request.Context(), auditActor(c), c.Param("reportId"))
+	if err != nil {
+		respondReportError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// ResolveReport records the moderator's decision on a claimed report
+func (h *ReportHandler) ResolveReport(c *gin.Context) {
+	var req models.ResolveReportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.moderationService.ResolveReport(c.Request.Context(), auditActor(c), c.Param("reportId"), &req); err != nil {
+		respondReportError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "report resolved"})
+}
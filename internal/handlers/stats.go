@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourusername/rbd-service/internal/services"
+)
+
+type StatsHandler struct {
+	statsService *services.StatsService
+}
+
+func NewStatsHandler() *StatsHandler {
+	return &StatsHandler{
+		statsService: services.NewStatsService(),
+	}
+}
+
+// GetStats returns the current user's overall trigger statistics
+func (h *StatsHandler) GetStats(c *gin.Context) {
+	userID := c.GetString("userID")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	stats, err := h.statsService.GetUserStats(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
+
+// GetFriendStats returns trigger statistics between the current user and a friend
+func (h *StatsHandler) GetFriendStats(c *gin.Context) {
+	userID := c.GetString("userID")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	friendUserID := c.Param("friendUserId")
+	if friendUserID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "friendUserId is required"})
+		return
+	}
+
+	stats, err := h.statsService.GetFriendStats(c.Request.Context(), userID, friendUserID)
+	if err != nil {
+		if err.Error() == "users are not friends" {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
@@ -0,0 +1,235 @@
+package handlers
+
+import (
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourusername/rbd-service/internal/models"
+	"github.com/yourusername/rbd-service/internal/services"
+	"github.com/yourusername/rbd-service/pkg/utils"
+)
+
+type UserHandler struct {
+	userService     *services.UserService
+	deletionService *services.AccountDeletionService
+}
+
+func NewUserHandler() *UserHandler {
+	return &UserHandler{
+		userService:     services.NewUserService(),
+		deletionService: services.NewAccountDeletionService(),
+	}
+}
+
+// ChangeUsername changes the current user's username
+func (h *UserHandler) ChangeUsername(c *gin.Context) {
+	userID := c.GetString("userID")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	var req models.ChangeUsernameRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := h.userService.ChangeUsername(c.Request.Context(), userID, req.Username)
+	if err != nil {
+		if strings.HasPrefix(err.Error(), "username_change_cooldown:") {
+			parts := strings.SplitN(err.Error(), ":", 2)
+			c.JSON(http.StatusTooManyRequests, gin.H{
+				"error":       "username_change_cooldown",
+				"availableAt": parts[1],
+			})
+			return
+		}
+
+		if err.Error() == "username already taken" {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"userId":   user.UserID,
+		"username": user.Username,
+	})
+}
+
+// GetMe returns the current user's profile
+func (h *UserHandler) GetMe(c *gin.Context) {
+	userID := c.GetString("userID")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	profile, err := h.userService.GetProfile(c.Request.Context(), userID, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, profile)
+}
+
+// GetProfile returns a friend's profile
+func (h *UserHandler) GetProfile(c *gin.Context) {
+	userID := c.GetString("userID")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	profileUserID := c.Param("userId")
+	if profileUserID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "userId is required"})
+		return
+	}
+
+	profile, err := h.userService.GetProfile(c.Request.Context(), userID, profileUserID)
+	if err != nil {
+		if err.Error() == "user not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, profile)
+}
+
+// UpdateProfile updates the current user's display name and bio
+func (h *UserHandler) UpdateProfile(c *gin.Context) {
+	userID := c.GetString("userID")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	var req models.UpdateProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	profile, err := h.userService.UpdateProfile(c.Request.Context(), userID, &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, profile)
+}
+
+// UploadAvatar accepts a multipart image upload (field "avatar") as the current user's avatar
+func (h *UserHandler) UploadAvatar(c *gin.Context) {
+	userID := c.GetString("userID")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	// Cap the request body before parsing the multipart form
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, utils.MaxImageUploadBytes+1<<20)
+
+	file, _, err := c.Request.FormFile("avatar")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "avatar file is required (max 5 MB)"})
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, utils.MaxImageUploadBytes+1))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	profile, err := h.userService.UploadAvatar(c.Request.Context(), userID, data)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, profile)
+}
+
+// DeleteAvatar removes the current user's avatar
+func (h *UserHandler) DeleteAvatar(c *gin.Context) {
+	userID := c.GetString("userID")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	if err := h.userService.DeleteAvatar(c.Request.Context(), userID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+// DeleteAccount schedules the current user's account for deletion.
+// All sessions are signed out; logging in again during the grace period cancels the deletion.
+func (h *UserHandler) DeleteAccount(c *gin.Context) {
+	userID := c.GetString("userID")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	var req models.DeleteAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	job, err := h.deletionService.RequestDeletion(c.Request.Context(), userID, req.Password)
+	if err != nil {
+		switch err.Error() {
+		case "password is incorrect":
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		case "user not found":
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusAccepted, models.DeleteAccountResponse{
+		Status:       job.Status,
+		ScheduledFor: job.ScheduledFor,
+	})
+}
+
+// GetActivity returns the current user's activity log (?cursor=&limit=)
+func (h *UserHandler) GetActivity(c *gin.Context) {
+	userID := c.GetString("userID")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	activity, err := h.userService.GetActivity(c.Request.Context(), userID, c.Query("cursor"), pageLimit(c))
+	if err != nil {
+		if err.Error() == "invalid cursor" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, activity)
+}
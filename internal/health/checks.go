@@ -0,0 +1,41 @@
+package health
+
+import (
+	"context"
+	"errors"
+
+	"github.com/yourusername/rbd-service/internal/config"
+	"github.com/yourusername/rbd-service/internal/search"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// CheckFirestore reads a probe document. A missing document still proves the
+// credentials and connection work, so only other errors fail the check.
+func CheckFirestore(ctx context.Context) error {
+	if config.FirestoreClient == nil {
+		return errors.New("firestore client not initialized")
+	}
+	_, err := config.FirestoreClient.Collection("health").Doc("probe").Get(ctx)
+	if err != nil && status.Code(err) != codes.NotFound {
+		return err
+	}
+	return nil
+}
+
+// CheckMessaging makes sure an FCM client can be created from the Firebase app
+func CheckMessaging(ctx context.Context) error {
+	if config.FirebaseApp == nil {
+		return errors.New("firebase app not initialized")
+	}
+	_, err := config.FirebaseApp.Messaging(ctx)
+	return err
+}
+
+// CheckSearchIndex fails until the in-memory user search index has been built
+func CheckSearchIndex(ctx context.Context) error {
+	if !search.GetIndex().Ready() {
+		return errors.New("search index not built yet")
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// State is where the process is in its lifecycle
+type State string
+
+const (
+	StateStarting     State = "starting"
+	StateReady        State = "ready"
+	StateShuttingDown State = "shutting_down"
+)
+
+// Status values reported for the service and for each check
+const (
+	StatusOK   = "ok"
+	StatusFail = "fail"
+)
+
+var state atomic.Value
+
+func init() {
+	state.Store(StateStarting)
+}
+
+// SetState records a lifecycle transition. Readiness fails in every state but ready.
+func SetState(s State) {
+	state.Store(s)
+}
+
+// CurrentState returns the current lifecycle state
+func CurrentState() State {
+	return state.Load().(State)
+}
+
+// CheckFunc probes one dependency; it must return once ctx is done
+type CheckFunc func(ctx context.Context) error
+
+type check struct {
+	name    string
+	timeout time.Duration
+	fn      CheckFunc
+}
+
+var (
+	checksMu sync.RWMutex
+	checks   []check
+)
+
+// Register adds a readiness check that fails if fn errors or takes longer than timeout
+func Register(name string, timeout time.Duration, fn CheckFunc) {
+	checksMu.Lock()
+	defer checksMu.Unlock()
+	checks = append(checks, check{name: name, timeout: timeout, fn: fn})
+}
+
+// CheckResult is the outcome of one readiness check
+type CheckResult struct {
+	Status     string  `json:"status"`
+	DurationMs float64 `json:"durationMs"`
+	Error      string  `json:"error,omitempty"`
+}
+
+// Report is the readiness breakdown served by /readyz
+type Report struct {
+	Status string                  `json:"status"`
+	State  State                   `json:"state"`
+	Checks map[string]*CheckResult `json:"checks,omitempty"`
+}
+
+// Ready reports whether the service should receive traffic
+func (r *Report) Ready() bool {
+	return r.Status == StatusOK
+}
+
+// Check runs every registered check concurrently, each under its own timeout.
+// Checks are skipped until the service has finished starting.
+func Check(ctx context.Context) *Report {
+	report := &Report{Status: StatusOK, State: CurrentState()}
+	if report.State != StateReady {
+		report.Status = StatusFail
+		return report
+	}
+
+	checksMu.RLock()
+	registered := append([]check(nil), checks...)
+	checksMu.RUnlock()
+
+	results := make([]*CheckResult, len(registered))
+	var wg sync.WaitGroup
+	for i, c := range registered {
+		wg.Add(1)
+		go func(i int, c check) {
+			defer wg.Done()
+			results[i] = run(ctx, c)
+		}(i, c)
+	}
+	wg.Wait()
+
+	report.Checks = make(map[string]*CheckResult, len(registered))
+	for i, c := range registered {
+		report.Checks[c.name] = results[i]
+		if results[i].Status != StatusOK {
+			report.Status = StatusFail
+		}
+	}
+	return report
+}
+
+func run(ctx context.Context, c check) *CheckResult {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				done <- fmt.Errorf("check panicked: %v", recovered)
+			}
+		}()
+		done <- c.fn(ctx)
+	}()
+
+	// Don't trust a check to honour its context: a hung dependency must still fail within the timeout
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = fmt.Errorf("timed out after %s", c.timeout)
+	}
+
+	result := &CheckResult{
+		Status:     StatusOK,
+		DurationMs: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		result.Status = StatusFail
+		result.Error = err.Error()
+	}
+	return result
+}
@@ -0,0 +1,56 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+type worker struct {
+	maxAge   time.Duration
+	lastBeat time.Time
+}
+
+var (
+	workersMu sync.Mutex
+	workers   = make(map[string]*worker)
+)
+
+// WatchWorker starts tracking a background worker. It counts as alive while it beats
+// at least once every maxAge; the clock starts now, so slow first runs aren't penalised.
+func WatchWorker(name string, maxAge time.Duration) {
+	workersMu.Lock()
+	defer workersMu.Unlock()
+	workers[name] = &worker{maxAge: maxAge, lastBeat: time.Now()}
+}
+
+// Beat records that a worker completed a run (successful or not); unwatched names are ignored
+func Beat(name string) {
+	workersMu.Lock()
+	defer workersMu.Unlock()
+	if w, ok := workers[name]; ok {
+		w.lastBeat = time.Now()
+	}
+}
+
+// CheckWorkers fails if any watched worker has missed its heartbeat
+func CheckWorkers(ctx context.Context) error {
+	workersMu.Lock()
+	defer workersMu.Unlock()
+
+	now := time.Now()
+	var stalled []string
+	for name, w := range workers {
+		if now.Sub(w.lastBeat) > w.maxAge {
+			stalled = append(stalled, fmt.Sprintf("%s (last run %s ago)", name, now.Sub(w.lastBeat).Round(time.Second)))
+		}
+	}
+	if len(stalled) > 0 {
+		sort.Strings(stalled)
+		return fmt.Errorf("stalled workers: %s", strings.Join(stalled, ", "))
+	}
+	return nil
+}
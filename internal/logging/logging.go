@@ -0,0 +1,97 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// redacted replaces the value of any attribute whose key looks like a credential
+const redacted = "[REDACTED]"
+
+// sensitiveKeys are attribute keys whose values are never logged.
+// Keys are compared case-insensitively with underscores and dashes removed, so "fcm_token" matches "fcmtoken".
+var sensitiveKeys = map[string]bool{
+	"token":         true,
+	"authorization": true,
+	"password":      true,
+	"newpassword":   true,
+	"fcmtoken":      true,
+	"recoverycode":  true,
+	"secret":        true,
+}
+
+var keyNormalizer = strings.NewReplacer("_", "", "-", "")
+
+type requestIDKey struct{}
+
+// WithRequestID returns a context whose log lines carry the request ID
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// RequestIDFrom returns the request ID stored in ctx (empty outside a request)
+func RequestIDFrom(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey{}).(string)
+	return requestID
+}
+
+// Init installs the default slog logger: JSON on stdout at LOG_LEVEL (debug, info, warn, error; default info).
+// LOG_FORMAT=text switches to human-readable output for local development.
+func Init() {
+	opts := &slog.HandlerOptions{
+		Level:       parseLevel(os.Getenv("LOG_LEVEL")),
+		ReplaceAttr: redact,
+	}
+
+	var handler slog.Handler
+	if strings.EqualFold(os.Getenv("LOG_FORMAT"), "text") {
+		handler = slog.NewTextHandler(os.Stdout, opts)
+	} else {
+		handler = slog.NewJSONHandler(os.Stdout, opts)
+	}
+
+	slog.SetDefault(slog.New(&contextHandler{Handler: handler}))
+}
+
+func parseLevel(value string) slog.Level {
+	var level slog.Level
+	if value == "" || level.UnmarshalText([]byte(value)) != nil {
+		return slog.LevelInfo
+	}
+	return level
+}
+
+// redact blanks out credential-like attributes wherever they appear
+func redact(groups []string, attr slog.Attr) slog.Attr {
+	if sensitiveKeys[keyNormalizer.Replace(strings.ToLower(attr.Key))] {
+		return slog.String(attr.Key, redacted)
+	}
+	return attr
+}
+
+// contextHandler adds the request ID and trace ID from the context to every record
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h *contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if requestID := RequestIDFrom(ctx); requestID != "" {
+		record.AddAttrs(slog.String("request_id", requestID))
+	}
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.IsValid() {
+		record.AddAttrs(slog.String("trace_id", spanContext.TraceID().String()))
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,129 @@
+package metrics
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Registry holds every metric the service exposes. A private registry (instead of the
+// global default) keeps third-party libraries from adding series we didn't ask for.
+var Registry = prometheus.NewRegistry()
+
+var (
+	// HTTPRequests counts served requests by route template and status code
+	HTTPRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "rbd_http_requests_total",
+		Help: "HTTP requests served, by method, route and status.",
+	}, []string{"method", "route", "status"})
+
+	// HTTPDuration tracks request latency by route template
+	HTTPDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "rbd_http_request_duration_seconds",
+		Help:    "HTTP request latency, by method and route.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	// Triggers counts trigger attempts by outcome (sent, cooldown, muted, muted_all, not_friends, ...)
+	Triggers = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "rbd_triggers_total",
+		Help: "Trigger attempts, by outcome.",
+	}, []string{"outcome"})
+
+	// FCMSends counts push notification sends by kind and result ("success" or an error class)
+	FCMSends = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "rbd_fcm_sends_total",
+		Help: "FCM sends, by notification kind and result.",
+	}, []string{"kind", "result"})
+
+	// RepositoryDuration tracks datastore call latency by repository operation
+	RepositoryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "rbd_repository_duration_seconds",
+		Help:    "Repository call latency, by operation.",
+		Buckets: []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5},
+	}, []string{"operation"})
+
+	// JobRuns counts background job runs by result
+	JobRuns = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "rbd_job_runs_total",
+		Help: "Background job runs, by job and result.",
+	}, []string{"job", "result"})
+
+	// JobDuration tracks how long each background job run takes
+	JobDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "rbd_job_duration_seconds",
+		Help:    "Background job run duration, by job.",
+		Buckets: []float64{.01, .1, .5, 1, 5, 15, 60, 300},
+	}, []string{"job"})
+
+	// DeletionJobsParked is how many account deletions were given up on after repeated failures
+	DeletionJobsParked = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "rbd_deletion_jobs_parked",
+		Help: "Account deletion jobs parked after repeated failures, waiting for an operator.",
+	})
+
+	// JobLastSuccess is the Unix time of each job's last successful run
+	JobLastSuccess = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "rbd_job_last_success_timestamp_seconds",
+		Help: "Unix time of the last successful run, by job.",
+	}, []string{"job"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		HTTPRequests,
+		HTTPDuration,
+		Triggers,
+		FCMSends,
+		RepositoryDuration,
+		JobRuns,
+		JobDuration,
+		JobLastSuccess,
+		DeletionJobsParked,
+	)
+}
+
+// ObserveSessions exposes the number of active sessions, read from fn on every scrape
+func ObserveSessions(fn func() int) {
+	Registry.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "rbd_active_sessions",
+		Help: "Unexpired sessions in the token store.",
+	}, func() float64 { return float64(fn()) }))
+}
+
+// ObserveRepository records how long a repository call took; use as `defer metrics.ObserveRepository(op, time.Now())`
+func ObserveRepository(operation string, start time.Time) {
+	RepositoryDuration.WithLabelValues(operation).Observe(time.Since(start).Seconds())
+}
+
+// ObserveJob records one run of a background job
+func ObserveJob(job string, start time.Time, err error) {
+	JobDuration.WithLabelValues(job).Observe(time.Since(start).Seconds())
+	if err != nil {
+		JobRuns.WithLabelValues(job, "error").Inc()
+		return
+	}
+	JobRuns.WithLabelValues(job, "success").Inc()
+	JobLastSuccess.WithLabelValues(job).SetToCurrentTime()
+}
+
+// Handler serves the registry in the Prometheus text format
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+}
+
+// NewServer returns a server that only exposes /metrics, for running on a private port
+func NewServer(addr string) *http.Server {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", Handler())
+	return &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+}
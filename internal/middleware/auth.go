@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"log/slog"
 	"net/http"
 	"strings"
 
@@ -41,6 +42,29 @@ func AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
+		// Suspended or banned accounts lose their sessions immediately
+		restriction, err := services.GetAccountRestriction(c.Request.Context(), userID)
+		if err != nil {
+			if err.Error() == "user not found" {
+				services.GetTokenStore().DeleteToken(token)
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid or expired token"})
+				c.Abort()
+				return
+			}
+			// Fail open: a Firestore hiccup shouldn't sign everyone out
+			slog.WarnContext(c.Request.Context(), "failed to check account status", "user_id", userID, "error", err)
+		}
+		if restriction != nil {
+			services.GetTokenStore().RevokeUserTokens(userID, "")
+			c.JSON(http.StatusForbidden, gin.H{
+				"error":  restriction.Error(),
+				"reason": restriction.Reason,
+				"until":  restriction.Until,
+			})
+			c.Abort()
+			return
+		}
+
 		// Store user ID in context for use in handlers
 		c.Set("userID", userID)
 		c.Set("token", token)
@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"os"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TrustProxies decides where c.ClientIP() may take the client address from. Login lockouts and
+// rate limits are keyed by it, so forwarding headers are ignored unless a proxy is configured:
+//   - TRUSTED_PROXIES is a comma-separated list of proxy IPs or CIDRs allowed to set X-Forwarded-For
+//   - TRUSTED_PLATFORM trusts a header set by the hosting platform: "cloudflare", "google", "flyio",
+//     or a header name. Only set it when the platform overwrites that header on every request.
+//
+// With neither set the connection's remote address is used.
+func TrustProxies(router *gin.Engine) error {
+	switch platform := strings.TrimSpace(os.Getenv("TRUSTED_PLATFORM")); strings.ToLower(platform) {
+	case "":
+	case "cloudflare":
+		router.TrustedPlatform = gin.PlatformCloudflare
+	case "google":
+		router.TrustedPlatform = gin.PlatformGoogleAppEngine
+	case "flyio":
+		router.TrustedPlatform = gin.PlatformFlyIO
+	default:
+		router.TrustedPlatform = platform
+	}
+
+	var proxies []string
+	for _, proxy := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			proxies = append(proxies, proxy)
+		}
+	}
+	return router.SetTrustedProxies(proxies)
+}
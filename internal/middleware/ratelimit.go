@@ -0,0 +1,116 @@
+package middleware
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourusername/rbd-service/internal/config"
+	"github.com/yourusername/rbd-service/internal/ratelimit"
+)
+
+// defaultRateLimits are the per route group limits.
+// Each can be overridden with RATE_LIMIT_<GROUP>=limit/period, e.g. RATE_LIMIT_SEARCH=60/1m.
+var defaultRateLimits = map[string]ratelimit.Rate{
+	"auth":           {Limit: 30, Period: time.Minute}, // Public, keyed by IP
+	"users":          {Limit: 60, Period: time.Minute},
+	"upload":         {Limit: 10, Period: time.Hour},
+	"export":         {Limit: 3, Period: 24 * time.Hour},
+	"friends":        {Limit: 60, Period: time.Minute},
+	"search":         {Limit: 30, Period: time.Minute},
+	"friend_request": {Limit: 20, Period: time.Hour},
+	"report":         {Limit: 10, Period: time.Hour},
+	"notifications":  {Limit: 120, Period: time.Minute},
+	"history":        {Limit: 60, Period: time.Minute},
+	"stats":          {Limit: 30, Period: time.Minute},
+	"admin":          {Limit: 120, Period: time.Minute},
+}
+
+var (
+	bucketStore     ratelimit.BucketStore
+	bucketStoreOnce sync.Once
+)
+
+// getBucketStore returns the shared token bucket store.
+// RATE_LIMIT_BACKEND=firestore shares buckets across instances; the default keeps them in memory.
+func getBucketStore() ratelimit.BucketStore {
+	bucketStoreOnce.Do(func() {
+		if os.Getenv("RATE_LIMIT_BACKEND") == "firestore" && config.FirestoreClient != nil {
+			bucketStore = ratelimit.NewFirestoreBucketStore(config.FirestoreClient, "rateLimits")
+			return
+		}
+		bucketStore = ratelimit.NewMemoryBucketStore(24 * time.Hour)
+	})
+	return bucketStore
+}
+
+// rateFor returns the configured rate for a route group
+func rateFor(group string) (ratelimit.Rate, bool) {
+	rate, ok := defaultRateLimits[group]
+	if value := os.Getenv("RATE_LIMIT_" + strings.ToUpper(group)); value != "" {
+		parsed, err := ratelimit.ParseRate(value)
+		if err != nil {
+			slog.Warn("ignoring invalid rate limit override", "variable", "RATE_LIMIT_"+strings.ToUpper(group), "error", err)
+		} else {
+			rate, ok = parsed, true
+		}
+	}
+	return rate, ok
+}
+
+// RateLimit applies the token bucket policy for a route group.
+// Requests are keyed by user ID when authenticated (so place it after AuthMiddleware) and by client IP otherwise;
+// the client IP only honours forwarding headers from proxies configured with TrustProxies.
+func RateLimit(group string) gin.HandlerFunc {
+	rate, ok := rateFor(group)
+	if !ok {
+		panic(fmt.Sprintf("unknown rate limit group %q", group))
+	}
+	if os.Getenv("RATE_LIMIT_ENABLED") == "false" {
+		return func(c *gin.Context) { c.Next() }
+	}
+	policy := strconv.Itoa(rate.Limit) + ";w=" + strconv.Itoa(int(rate.Period.Seconds()))
+
+	return func(c *gin.Context) {
+		key := "ip:" + c.ClientIP()
+		if userID := c.GetString("userID"); userID != "" {
+			key = "user:" + userID
+		}
+
+		result, err := ratelimit.Take(c.Request.Context(), getBucketStore(), group+"|"+key, rate)
+		if err != nil {
+			// Fail open: a limiter outage shouldn't take the API down with it
+			slog.WarnContext(c.Request.Context(), "rate limiter unavailable", "group", group, "error", err)
+			c.Next()
+			return
+		}
+
+		c.Header("RateLimit-Policy", policy)
+		c.Header("RateLimit-Limit", strconv.Itoa(result.Limit))
+		c.Header("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		c.Header("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.Reset)))
+
+		if !result.Allowed {
+			retryAfter := ceilSeconds(result.RetryAfter)
+			c.Header("Retry-After", strconv.Itoa(retryAfter))
+			c.JSON(http.StatusTooManyRequests, gin.H{
+				"error":      "rate_limited",
+				"retryAfter": retryAfter,
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int((d + time.Second - 1) / time.Second)
+}
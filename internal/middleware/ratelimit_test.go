@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func rateLimitedRouter(t *testing.T) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	if err := TrustProxies(router); err != nil {
+		t.Fatal(err)
+	}
+	router.GET("/limited", RateLimit("auth"), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	return router
+}
+
+// countAllowed sends n requests from remoteAddr, each claiming a different client in X-Forwarded-For
+func countAllowed(router *gin.Engine, remoteAddr string, n int) int {
+	allowed := 0
+	for i := 0; i < n; i++ {
+		req := httptest.NewRequest(http.MethodGet, "/limited", nil)
+		req.RemoteAddr = remoteAddr
+		req.Header.Set("X-Forwarded-For", fmt.Sprintf("198.51.100.%d", i+1))
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		if rec.Code == http.StatusOK {
+			allowed++
+		}
+	}
+	return allowed
+}
+
+func TestRateLimitIgnoresForwardedForFromUntrustedPeers(t *testing.T) {
+	t.Setenv("RATE_LIMIT_AUTH", "3/1m")
+	t.Setenv("TRUSTED_PROXIES", "")
+	t.Setenv("TRUSTED_PLATFORM", "")
+
+	if allowed := countAllowed(rateLimitedRouter(t), "192.0.2.10:4000", 10); allowed != 3 {
+		t.Fatalf("rotating X-Forwarded-For got %d requests through, expected the limit of 3", allowed)
+	}
+}
+
+func TestRateLimitUsesForwardedForFromTrustedProxy(t *testing.T) {
+	t.Setenv("RATE_LIMIT_AUTH", "3/1m")
+	t.Setenv("TRUSTED_PROXIES", "192.0.2.20")
+	t.Setenv("TRUSTED_PLATFORM", "")
+
+	// Behind a trusted proxy each forwarded client gets its own bucket
+	if allowed := countAllowed(rateLimitedRouter(t), "192.0.2.20:4000", 10); allowed != 10 {
+		t.Fatalf("expected every forwarded client to be allowed, got %d of 10", allowed)
+	}
+}
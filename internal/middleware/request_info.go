@@ -0,0 +1,19 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/yourusername/rbd-service/internal/audit"
+)
+
+// RequestInfo attaches the client's IP and user agent to the request context so
+// services can include them in audit entries
+func RequestInfo() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := audit.WithRequestInfo(c.Request.Context(), audit.RequestInfo{
+			IP:        c.ClientIP(),
+			UserAgent: c.Request.UserAgent(),
+		})
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
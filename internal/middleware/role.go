@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourusername/rbd-service/internal/models"
+	"github.com/yourusername/rbd-service/internal/services"
+)
+
+// RequireRole only lets through users with at least the given role. Must run after AuthMiddleware.
+// The role is read fresh from the user record on every request so demotions apply immediately.
+func RequireRole(role models.Role) gin.HandlerFunc {
+	adminService := services.NewAdminService()
+
+	return func(c *gin.Context) {
+		userID := c.GetString("userID")
+		if userID == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+			c.Abort()
+			return
+		}
+
+		userRole, err := adminService.GetRole(c.Request.Context(), userID)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+			c.Abort()
+			return
+		}
+
+		if userRole.Rank() < role.Rank() {
+			c.JSON(http.StatusForbidden, gin.H{"error": "insufficient role"})
+			c.Abort()
+			return
+		}
+
+		// Store the role in context for handlers (e.g. for audit entries)
+		c.Set("role", string(userRole))
+		c.Next()
+	}
+}
@@ -0,0 +1,32 @@
+package migrations
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"cloud.google.com/go/firestore"
+)
+
+func init() {
+	register(Migration{
+		Name:        "deletion-retry-at",
+		Description: "Set retryAt on failed deletion jobs from before retries were backed off, so workers pick them up again",
+		Run:         backfillDeletionRetryAt,
+	})
+}
+
+func backfillDeletionRetryAt(ctx context.Context, client *firestore.Client) error {
+	now := time.Now()
+	updated, err := updateEach(ctx, client, "deletionJobs", func(doc *firestore.DocumentSnapshot) []firestore.Update {
+		if status, _ := doc.DataAt("status"); status != "failed" {
+			return nil
+		}
+		if _, err := doc.DataAt("retryAt"); err == nil {
+			return nil
+		}
+		return []firestore.Update{{Path: "retryAt", Value: now}}
+	})
+	slog.InfoContext(ctx, "deletion-retry-at: done", "updated", updated)
+	return err
+}
@@ -0,0 +1,34 @@
+package migrations
+
+import (
+	"context"
+	"log/slog"
+
+	"cloud.google.com/go/firestore"
+	"github.com/yourusername/rbd-service/internal/models"
+)
+
+func init() {
+	register(Migration{
+		Name:        "history-pair-key",
+		Description: "Backfill pairKey on history records so both directions can be queried together",
+		Run:         backfillHistoryPairKey,
+	})
+}
+
+func backfillHistoryPairKey(ctx context.Context, client *firestore.Client) error {
+	updated, err := updateEach(ctx, client, "history", func(doc *firestore.DocumentSnapshot) []firestore.Update {
+		var history models.History
+		if err := doc.DataTo(&history); err != nil {
+			return nil
+		}
+
+		pairKey := models.PairKey(history.SenderID, history.ReceiverID)
+		if history.PairKey == pairKey {
+			return nil
+		}
+		return []firestore.Update{{Path: "pairKey", Value: pairKey}}
+	})
+	slog.InfoContext(ctx, "history-pair-key: done", "updated", updated)
+	return err
+}
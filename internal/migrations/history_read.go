@@ -0,0 +1,28 @@
+package migrations
+
+import (
+	"context"
+	"log/slog"
+
+	"cloud.google.com/go/firestore"
+)
+
+func init() {
+	register(Migration{
+		Name:        "history-read",
+		Description: "Backfill read=false on history records written before the inbox existed, so unread counts include them",
+		Run:         backfillHistoryRead,
+	})
+}
+
+func backfillHistoryRead(ctx context.Context, client *firestore.Client) error {
+	updated, err := updateEach(ctx, client, "history", func(doc *firestore.DocumentSnapshot) []firestore.Update {
+		// Only touch records that never had the field; ones already marked read stay read
+		if _, err := doc.DataAt("read"); err == nil {
+			return nil
+		}
+		return []firestore.Update{{Path: "read", Value: false}}
+	})
+	slog.InfoContext(ctx, "history-read: done", "updated", updated)
+	return err
+}
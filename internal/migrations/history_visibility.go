@@ -0,0 +1,34 @@
+package migrations
+
+import (
+	"context"
+	"log/slog"
+
+	"cloud.google.com/go/firestore"
+	"github.com/yourusername/rbd-service/internal/models"
+)
+
+func init() {
+	register(Migration{
+		Name:        "history-visibility",
+		Description: "Backfill visibleTo on history records written before per-user deletion existed",
+		Run:         backfillHistoryVisibility,
+	})
+}
+
+func backfillHistoryVisibility(ctx context.Context, client *firestore.Client) error {
+	updated, err := updateEach(ctx, client, "history", func(doc *firestore.DocumentSnapshot) []firestore.Update {
+		// Only touch records that never had the field; an empty list means both sides deleted it
+		if _, err := doc.DataAt("visibleTo"); err == nil {
+			return nil
+		}
+
+		var history models.History
+		if err := doc.DataTo(&history); err != nil {
+			return nil
+		}
+		return []firestore.Update{{Path: "visibleTo", Value: []string{history.SenderID, history.ReceiverID}}}
+	})
+	slog.InfoContext(ctx, "history-visibility: done", "updated", updated)
+	return err
+}
@@ -0,0 +1,88 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/api/iterator"
+)
+
+// Migration is a one-off data fix that can be run safely more than once
+type Migration struct {
+	Name        string
+	Description string
+	Run         func(ctx context.Context, client *firestore.Client) error
+}
+
+var registry = map[string]Migration{}
+
+// register adds a migration to the registry (called from init in each migration file)
+func register(m Migration) {
+	registry[m.Name] = m
+}
+
+// All returns every registered migration sorted by name
+func All() []Migration {
+	var all []Migration
+	for _, m := range registry {
+		all = append(all, m)
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].Name < all[j].Name })
+	return all
+}
+
+// Run executes a migration by name
+func Run(ctx context.Context, client *firestore.Client, name string) error {
+	m, ok := registry[name]
+	if !ok {
+		return fmt.Errorf("unknown migration %q", name)
+	}
+	return m.Run(ctx, client)
+}
+
+// updateEach walks a whole collection and applies the updates returned by fn, in batches.
+// fn returns nil when a document needs no changes.
+func updateEach(ctx context.Context, client *firestore.Client, collection string, fn func(doc *firestore.DocumentSnapshot) []firestore.Update) (int, error) {
+	iter := client.Collection(collection).Documents(ctx)
+
+	batch := client.Batch()
+	count := 0
+	updated := 0
+
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return updated, err
+		}
+
+		updates := fn(doc)
+		if len(updates) == 0 {
+			continue
+		}
+		batch.Update(doc.Ref, updates)
+		count++
+		updated++
+
+		// Firestore batch limit is 500
+		if count >= 500 {
+			if _, err := batch.Commit(ctx); err != nil {
+				return updated, err
+			}
+			batch = client.Batch()
+			count = 0
+		}
+	}
+
+	if count > 0 {
+		if _, err := batch.Commit(ctx); err != nil {
+			return updated, err
+		}
+	}
+
+	return updated, nil
+}
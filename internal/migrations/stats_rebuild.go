@@ -0,0 +1,88 @@
+package migrations
+
+import (
+	"context"
+	"log/slog"
+
+	"cloud.google.com/go/firestore"
+	"github.com/yourusername/rbd-service/internal/models"
+	"github.com/yourusername/rbd-service/internal/repository"
+	"google.golang.org/api/iterator"
+)
+
+func init() {
+	register(Migration{
+		Name:        "stats-rebuild",
+		Description: "Rebuild trigger statistics aggregates from the full history",
+		Run:         rebuildStats,
+	})
+}
+
+// userAggregates holds everything rebuilt for one user
+type userAggregates struct {
+	overall   *models.TriggerStats
+	perFriend map[string]*models.TriggerStats
+}
+
+func rebuildStats(ctx context.Context, client *firestore.Client) error {
+	aggregates := map[string]*userAggregates{}
+	get := func(userID string) *userAggregates {
+		if aggregates[userID] == nil {
+			aggregates[userID] = &userAggregates{
+				overall:   &models.TriggerStats{},
+				perFriend: map[string]*models.TriggerStats{},
+			}
+		}
+		return aggregates[userID]
+	}
+	friend := func(userID, friendUserID string) *models.TriggerStats {
+		agg := get(userID)
+		if agg.perFriend[friendUserID] == nil {
+			agg.perFriend[friendUserID] = &models.TriggerStats{}
+		}
+		return agg.perFriend[friendUserID]
+	}
+
+	// Oldest first so streaks are rebuilt in order
+	iter := client.Collection("history").OrderBy("triggeredAt", firestore.Asc).Documents(ctx)
+	processed := 0
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		var history models.History
+		if err := doc.DataTo(&history); err != nil {
+			continue
+		}
+		if history.Type == models.HistoryTypeResponse {
+			continue
+		}
+
+		get(history.SenderID).overall.AddTrigger(history.TriggeredAt, true, history.ReceiverID)
+		get(history.ReceiverID).overall.AddTrigger(history.TriggeredAt, false, history.SenderID)
+		friend(history.SenderID, history.ReceiverID).AddTrigger(history.TriggeredAt, true, "")
+		friend(history.ReceiverID, history.SenderID).AddTrigger(history.TriggeredAt, false, "")
+
+		if history.RespondedAt != nil {
+			took := history.RespondedAt.Sub(history.TriggeredAt)
+			get(history.ReceiverID).overall.AddResponse(took)
+			friend(history.ReceiverID, history.SenderID).AddResponse(took)
+		}
+		processed++
+	}
+
+	statsRepo := repository.NewStatsRepository()
+	for userID, agg := range aggregates {
+		if err := statsRepo.SetStats(ctx, userID, agg.overall, agg.perFriend); err != nil {
+			return err
+		}
+	}
+
+	slog.InfoContext(ctx, "stats-rebuild: done", "triggers", processed, "users", len(aggregates))
+	return nil
+}
@@ -0,0 +1,77 @@
+package migrations
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/yourusername/rbd-service/internal/models"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func init() {
+	register(Migration{
+		Name:        "username-reservations",
+		Description: "Reserve every existing username and report case-insensitive collisions",
+		Run:         reserveExistingUsernames,
+	})
+}
+
+func reserveExistingUsernames(ctx context.Context, client *firestore.Client) error {
+	// Group users by lowercased username
+	byName := map[string][]*models.User{}
+	iter := client.Collection("users").Documents(ctx)
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		var user models.User
+		if err := doc.DataTo(&user); err != nil {
+			continue
+		}
+		lower := strings.ToLower(user.Username)
+		byName[lower] = append(byName[lower], &user)
+	}
+
+	reserved := 0
+	collisions := 0
+	for lower, users := range byName {
+		// The oldest account keeps the name; the rest must be renamed by an operator
+		sort.Slice(users, func(i, j int) bool { return users[i].CreatedAt.Before(users[j].CreatedAt) })
+		if len(users) > 1 {
+			collisions++
+			for _, user := range users[1:] {
+				slog.WarnContext(ctx, "username-reservations: collision needs a rename",
+					"username", user.Username, "user_id", user.UserID,
+					"kept_username", users[0].Username, "kept_user_id", users[0].UserID)
+			}
+		}
+
+		reservation := models.UsernameReservation{
+			UsernameLower: lower,
+			UserID:        users[0].UserID,
+			ReservedAt:    time.Now(),
+		}
+		_, err := client.Collection("usernames").Doc(lower).Create(ctx, reservation)
+		if status.Code(err) == codes.AlreadyExists {
+			continue // Reserved by a previous run or a new registration
+		}
+		if err != nil {
+			return err
+		}
+		reserved++
+	}
+
+	slog.InfoContext(ctx, "username-reservations: done", "reserved", reserved, "collisions", collisions)
+	return nil
+}
@@ -0,0 +1,35 @@
+package migrations
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+
+	"cloud.google.com/go/firestore"
+	"github.com/yourusername/rbd-service/internal/models"
+)
+
+func init() {
+	register(Migration{
+		Name:        "users-username-lower",
+		Description: "Backfill usernameLower on users so search can use an indexed prefix query",
+		Run:         backfillUsernameLower,
+	})
+}
+
+func backfillUsernameLower(ctx context.Context, client *firestore.Client) error {
+	updated, err := updateEach(ctx, client, "users", func(doc *firestore.DocumentSnapshot) []firestore.Update {
+		var user models.User
+		if err := doc.DataTo(&user); err != nil {
+			return nil
+		}
+
+		lower := strings.ToLower(user.Username)
+		if user.UsernameLower == lower {
+			return nil
+		}
+		return []firestore.Update{{Path: "usernameLower", Value: lower}}
+	})
+	slog.InfoContext(ctx, "users-username-lower: done", "updated", updated)
+	return err
+}
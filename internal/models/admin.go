@@ -0,0 +1,95 @@
+package models
+
+import "time"
+
+// Role controls access to moderation and admin endpoints
+type Role string
+
+const (
+	RoleUser      Role = "user"
+	RoleModerator Role = "moderator"
+	RoleAdmin     Role = "admin"
+)
+
+// Rank returns the role's privilege level (higher = more privileged)
+func (r Role) Rank() int {
+	switch r {
+	case RoleModerator:
+		return 1
+	case RoleAdmin:
+		return 2
+	default:
+		return 0
+	}
+}
+
+// AccountStatus is whether a user may use the service
+type AccountStatus string
+
+const (
+	AccountActive    AccountStatus = "active"
+	AccountSuspended AccountStatus = "suspended" // Temporary or open-ended; lifted by a moderator or at StatusUntil
+	AccountBanned    AccountStatus = "banned"    // Permanent until an admin lifts it
+)
+
+// AccountRestriction describes why a user can't use the service.
+// It doubles as the error returned when a restricted user tries to log in or act.
+type AccountRestriction struct {
+	Status AccountStatus `json:"status"`
+	Reason string        `json:"reason,omitempty"`
+	Until  *time.Time    `json:"until,omitempty"`
+}
+
+// Error returns "account_suspended" or "account_banned"
+func (r *AccountRestriction) Error() string {
+	return "account_" + string(r.Status)
+}
+
+// AdminUserView is what moderators and admins see when looking up a user
+type AdminUserView struct {
+	UserID               string        `json:"userId"`
+	Username             string        `json:"username"`
+	DisplayName          string        `json:"displayName,omitempty"`
+	Role                 Role          `json:"role"`
+	Status               AccountStatus `json:"status"`
+	StatusReason         string        `json:"statusReason,omitempty"`
+	StatusUntil          *time.Time    `json:"statusUntil,omitempty"`
+	StatusChangedAt      *time.Time    `json:"statusChangedAt,omitempty"`
+	StatusChangedBy      string        `json:"statusChangedBy,omitempty"`
+	CreatedAt            time.Time     `json:"createdAt"`
+	MutedAll             bool          `json:"mutedAll"`
+	PushTokenRegistered  bool          `json:"pushTokenRegistered"`
+	FriendCount          int           `json:"friendCount"`
+	ActiveSessions       int           `json:"activeSessions"`
+	DeletionScheduledFor *time.Time    `json:"deletionScheduledFor,omitempty"`
+}
+
+// SuspendUserRequest represents the request to suspend an account.
+// Without Until the suspension lasts until it's lifted by hand.
+type SuspendUserRequest struct {
+	Reason string     `json:"reason" binding:"required,max=500"`
+	Until  *time.Time `json:"until"`
+}
+
+// BanUserRequest represents the request to ban an account
+type BanUserRequest struct {
+	Reason string `json:"reason" binding:"required,max=500"`
+}
+
+// AdminActionRequest represents an admin action that only needs a reason
+type AdminActionRequest struct {
+	Reason string `json:"reason" binding:"max=500"`
+}
+
+// SetRoleRequest represents the request to change a user's role
+type SetRoleRequest struct {
+	Role   Role   `json:"role" binding:"required,oneof=user moderator admin"`
+	Reason string `json:"reason" binding:"max=500"`
+}
+
+// AdminFriendship is one of a user's friendships as seen by moderators
+type AdminFriendship struct {
+	*Friendship
+	OtherUserID   string `json:"otherUserId"`
+	OtherUsername string `json:"otherUsername"`
+}
@@ -0,0 +1,99 @@
+package models
+
+import "time"
+
+// Audit log actions
+const (
+	AuditAdminViewUser        = "admin.view_user"
+	AuditAdminViewFriendships = "admin.view_friendships"
+	AuditAdminSuspend         = "admin.suspend"
+	AuditAdminBan             = "admin.ban"
+	AuditAdminUnsuspend       = "admin.unsuspend"
+	AuditAdminRevokeSessions  = "admin.revoke_sessions"
+	AuditAdminClearCooldowns  = "admin.clear_cooldowns"
+	AuditAdminSetRole         = "admin.set_role"
+
+	AuditReportClaim   = "report.claim"
+	AuditReportResolve = "report.resolve"
+
+	AuditSuspensionExpired = "account.suspension_expired"
+	AuditAutoSuspended     = "account.auto_suspended"
+	AuditUsernameChange    = "account.username_change"
+	AuditDeletionRequested = "account.deletion_requested"
+	AuditDeletionCancelled = "account.deletion_cancelled"
+
+	AuditRegister                = "auth.register"
+	AuditLogin                   = "auth.login"
+	AuditLoginFailed             = "auth.login_failed"
+	AuditTokenRefresh            = "auth.token_refresh"
+	AuditPasswordChange          = "auth.password_change"
+	AuditPasswordReset           = "auth.password_reset"
+	AuditRecoveryCodesRegenerate = "auth.recovery_codes_regenerate"
+	AuditFCMTokenUpdate          = "auth.fcm_token_update"
+
+	AuditFriendRequest  = "friend.request"
+	AuditFriendAccept   = "friend.accept"
+	AuditFriendReject   = "friend.reject"
+	AuditFriendRemove   = "friend.remove"
+	AuditFriendMute     = "friend.mute"
+	AuditMuteAll        = "friend.mute_all"
+	AuditFriendCooldown = "friend.cooldown"
+
+	AuditTriggerSent      = "notification.trigger"
+	AuditTriggerResponded = "notification.respond"
+)
+
+// AuditActorSystem is the actor ID of actions taken by background jobs
+const AuditActorSystem = "system"
+
+// AuditActorAnonymous is the actor ID of unauthenticated requests, such as failed logins
+const AuditActorAnonymous = "anonymous"
+
+// AuditActor identifies who performed an audited action
+type AuditActor struct {
+	UserID string
+	Role   Role
+}
+
+// AuditEntry is one append-only record in the auditLog collection
+type AuditEntry struct {
+	EntryID      string                 `firestore:"entryId" json:"entryId"`
+	ActorID      string                 `firestore:"actorId" json:"actorId"`
+	ActorRole    Role                   `firestore:"actorRole" json:"actorRole"`
+	Action       string                 `firestore:"action" json:"action"`
+	TargetUserID string                 `firestore:"targetUserId,omitempty" json:"targetUserId,omitempty"`
+	Reason       string                 `firestore:"reason,omitempty" json:"reason,omitempty"`
+	Before       map[string]interface{} `firestore:"before,omitempty" json:"before,omitempty"` // Changed values before the action
+	After        map[string]interface{} `firestore:"after,omitempty" json:"after,omitempty"`   // Changed values after the action
+	Details      map[string]interface{} `firestore:"details,omitempty" json:"details,omitempty"`
+	IP           string                 `firestore:"ip,omitempty" json:"ip,omitempty"`
+	UserAgent    string                 `firestore:"userAgent,omitempty" json:"userAgent,omitempty"`
+	VisibleTo    []string               `firestore:"visibleTo,omitempty" json:"-"` // Users who see this entry in their activity
+	CreatedAt    time.Time              `firestore:"createdAt" json:"createdAt"`
+}
+
+// ActivityEntry is an audit entry as shown to the user it concerns.
+// Staff identities and their network details are left out.
+type ActivityEntry struct {
+	Action       string                 `json:"action"`
+	ByYou        bool                   `json:"byYou"`
+	TargetUserID string                 `json:"targetUserId,omitempty"`
+	Reason       string                 `json:"reason,omitempty"`
+	Before       map[string]interface{} `json:"before,omitempty"`
+	After        map[string]interface{} `json:"after,omitempty"`
+	IP           string                 `json:"ip,omitempty"`
+	UserAgent    string                 `json:"userAgent,omitempty"`
+	CreatedAt    time.Time              `json:"createdAt"`
+}
+
+// ActivityResponse represents a page of the current user's activity
+type ActivityResponse struct {
+	Entries    []*ActivityEntry `json:"entries"`
+	NextCursor string           `json:"nextCursor,omitempty"`
+}
+
+// AuditLogResponse represents a page of audit log entries
+type AuditLogResponse struct {
+	Entries    []*AuditEntry `json:"entries"`
+	NextCursor string        `json:"nextCursor,omitempty"`
+}
@@ -0,0 +1,56 @@
+package models
+
+import "time"
+
+// DeletionStatus is the state of an account deletion job
+type DeletionStatus string
+
+const (
+	DeletionScheduled DeletionStatus = "scheduled" // Waiting out the grace period; logging in cancels it
+	DeletionRunning   DeletionStatus = "running"
+	DeletionCompleted DeletionStatus = "completed"
+	DeletionCancelled DeletionStatus = "cancelled"
+	DeletionFailed    DeletionStatus = "failed" // Retried with backoff once RetryAt has passed
+	DeletionParked    DeletionStatus = "parked" // Gave up after repeated failures; left for an operator (see rbd_deletion_jobs_parked)
+)
+
+// DeletedUserID replaces a deleted user's ID in records kept for other users, such as reports they filed
+const DeletedUserID = "deleted"
+
+// DeletionJob tracks the deletion of one account (deletionJobs/{userId}).
+// Steps are idempotent and recorded as they finish, so an interrupted job resumes where it stopped.
+type DeletionJob struct {
+	UserID         string         `firestore:"userId" json:"userId"`
+	Status         DeletionStatus `firestore:"status" json:"status"`
+	RequestedAt    time.Time      `firestore:"requestedAt" json:"requestedAt"`
+	ScheduledFor   time.Time      `firestore:"scheduledFor" json:"scheduledFor"` // End of the grace period
+	LeaseUntil     *time.Time     `firestore:"leaseUntil,omitempty" json:"-"`    // Set while a worker is running the job
+	CompletedSteps []string       `firestore:"completedSteps" json:"completedSteps"`
+	Deleted        map[string]int `firestore:"deleted" json:"deleted"` // Step -> documents deleted
+	Attempts       int            `firestore:"attempts" json:"attempts"`
+	LastError      string         `firestore:"lastError,omitempty" json:"lastError,omitempty"`
+	RetryAt        *time.Time     `firestore:"retryAt,omitempty" json:"retryAt,omitempty"` // When a failed job may be retried
+	CompletedAt    *time.Time     `firestore:"completedAt,omitempty" json:"completedAt,omitempty"`
+	UpdatedAt      time.Time      `firestore:"updatedAt" json:"updatedAt"`
+}
+
+// StepDone reports whether a step has already been completed
+func (j *DeletionJob) StepDone(step string) bool {
+	for _, s := range j.CompletedSteps {
+		if s == step {
+			return true
+		}
+	}
+	return false
+}
+
+// DeleteAccountRequest represents the account deletion request body
+type DeleteAccountRequest struct {
+	Password string `json:"password" binding:"required"`
+}
+
+// DeleteAccountResponse represents the account deletion response
+type DeleteAccountResponse struct {
+	Status       DeletionStatus `json:"status"`
+	ScheduledFor time.Time      `json:"scheduledFor"`
+}
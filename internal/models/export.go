@@ -0,0 +1,89 @@
+package models
+
+import "time"
+
+// ExportFormatVersion is the version of the data export archive layout (see docs/data-export.md).
+// Bump it whenever a file is added, removed or changes shape.
+const ExportFormatVersion = 1
+
+// ExportStatus is the state of a data export
+type ExportStatus string
+
+const (
+	ExportPending ExportStatus = "pending" // Archive is being built
+	ExportReady   ExportStatus = "ready"
+	ExportFailed  ExportStatus = "failed"
+	ExportExpired ExportStatus = "expired" // Archive has been deleted
+)
+
+// DataExport tracks one personal data export (exports/{exportId})
+type DataExport struct {
+	ExportID          string       `firestore:"exportId" json:"exportId"`
+	UserID            string       `firestore:"userId" json:"userId"`
+	Status            ExportStatus `firestore:"status" json:"status"`
+	FormatVersion     int          `firestore:"formatVersion" json:"formatVersion"`
+	RequestedAt       time.Time    `firestore:"requestedAt" json:"requestedAt"`
+	CompletedAt       *time.Time   `firestore:"completedAt,omitempty" json:"completedAt,omitempty"`
+	ExpiresAt         *time.Time   `firestore:"expiresAt,omitempty" json:"expiresAt,omitempty"` // When the archive is deleted
+	BlobKey           string       `firestore:"blobKey,omitempty" json:"-"`
+	SizeBytes         int          `firestore:"sizeBytes,omitempty" json:"sizeBytes,omitempty"`
+	DownloadTokenHash string       `firestore:"downloadTokenHash,omitempty" json:"-"` // SHA-256 of the current download link token
+	DownloadExpiresAt *time.Time   `firestore:"downloadExpiresAt,omitempty" json:"-"`
+	Error             string       `firestore:"error,omitempty" json:"error,omitempty"`
+}
+
+// DataExportResponse represents an export's status, with a short-lived download link once it's ready
+type DataExportResponse struct {
+	*DataExport
+	DownloadURL       string     `json:"downloadUrl,omitempty"`
+	DownloadExpiresAt *time.Time `json:"downloadExpiresAt,omitempty"`
+}
+
+// ExportManifest is manifest.json in the export archive
+type ExportManifest struct {
+	FormatVersion int       `json:"formatVersion"`
+	GeneratedAt   time.Time `json:"generatedAt"`
+	UserID        string    `json:"userId"`
+	Files         []string  `json:"files"`
+}
+
+// ExportProfile is profile.json in the export archive
+type ExportProfile struct {
+	UserID               string            `json:"userId"`
+	Username             string            `json:"username"`
+	DisplayName          string            `json:"displayName,omitempty"`
+	Bio                  string            `json:"bio,omitempty"`
+	AvatarURL            string            `json:"avatarUrl,omitempty"`
+	CreatedAt            time.Time         `json:"createdAt"`
+	MutedAll             bool              `json:"mutedAll"`
+	PushTokenRegistered  bool              `json:"pushTokenRegistered"`
+	RecoveryCodesLeft    int               `json:"recoveryCodesLeft"`
+	UsernameChangedAt    *time.Time        `json:"usernameChangedAt,omitempty"`
+	UsernameHistory      []*UsernameChange `json:"usernameHistory"`
+	DeletionScheduledFor *time.Time        `json:"deletionScheduledFor,omitempty"`
+}
+
+// ExportFriendship is one entry of friendships.json in the export archive, seen from the exporting user's side
+type ExportFriendship struct {
+	UserID                string           `json:"userId"`
+	Username              string           `json:"username"`
+	Status                FriendshipStatus `json:"status"`
+	Direction             string           `json:"direction"` // "sent" or "received": who made the request
+	RequestedAt           time.Time        `json:"requestedAt"`
+	AcceptedAt            *time.Time       `json:"acceptedAt,omitempty"`
+	Muted                 bool             `json:"muted"`                 // You muted them
+	CooldownMinutes       int              `json:"cooldownMinutes"`       // Cooldown you set for them
+	FriendCooldownMinutes int              `json:"friendCooldownMinutes"` // Cooldown they set for you
+}
+
+// ExportSession is one entry of sessions.json in the export archive
+type ExportSession struct {
+	CreatedAt time.Time `json:"createdAt"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// ExportHistoryEntry is one entry of history.json in the export archive
+type ExportHistoryEntry struct {
+	*History
+	HiddenByYou bool `json:"hiddenByYou"` // You deleted it from your history; it's kept until your friend does too
+}
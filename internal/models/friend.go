@@ -25,10 +25,21 @@ type Friendship struct {
 	User2CooldownMinutes int              `firestore:"user2CooldownMinutes" json:"user2CooldownMinutes"` // Cooldown User2 sets for User1
 }
 
+// Relationship describes how another user relates to the current user
+type Relationship string
+
+const (
+	RelationshipFriend          Relationship = "friend"
+	RelationshipPendingSent     Relationship = "pending_sent"     // Current user sent them a request
+	RelationshipPendingReceived Relationship = "pending_received" // They sent the current user a request
+	RelationshipNone            Relationship = "none"
+)
+
 // FriendInfo represents friend information for display
 type FriendInfo struct {
 	UserID            string `json:"userId"`
 	Username          string `json:"username"`
+	AvatarURL         string `json:"avatarUrl,omitempty"`
 	IsMuted           bool   `json:"isMuted"`           // Have I muted this friend? (shows red button on my side)
 	IsMutedBy         bool   `json:"isMutedBy"`         // Has this friend muted me? (disables my trigger button)
 	CooldownRemaining int    `json:"cooldownRemaining"` // Remaining seconds until can trigger again
@@ -41,6 +52,7 @@ type FriendRequest struct {
 	RequestID   string    `json:"requestId"`
 	Username    string    `json:"username"`
 	UserID      string    `json:"userId"`
+	AvatarURL   string    `json:"avatarUrl,omitempty"`
 	RequestedAt time.Time `json:"requestedAt"`
 }
 
@@ -57,6 +69,29 @@ type AcceptRejectRequestBody struct {
 // SearchUsersRequest represents the search users request body
 type SearchUsersRequest struct {
 	Username string `json:"username" binding:"required"`
+	Cursor   string `json:"cursor"`
+	Limit    int    `json:"limit" binding:"omitempty,min=1,max=50"`
+}
+
+// UserSearchResult represents a user returned by search
+type UserSearchResult struct {
+	UserID       string       `json:"userId"`
+	Username     string       `json:"username"`
+	Relationship Relationship `json:"relationship"`
+
+	// Deprecated: search used to return FriendInfo entries and these fields were always zero.
+	// They are kept so older clients still decode the response; use Relationship instead.
+	IsMuted           bool `json:"isMuted"`
+	IsMutedBy         bool `json:"isMutedBy"`
+	CooldownRemaining int  `json:"cooldownRemaining"`
+	CanTrigger        bool `json:"canTrigger"`
+	CooldownMinutes   int  `json:"cooldownMinutes"`
+}
+
+// SearchUsersResponse represents a page of search results
+type SearchUsersResponse struct {
+	Users      []*UserSearchResult `json:"users"`
+	NextCursor string              `json:"nextCursor,omitempty"`
 }
 
 // MuteFriendRequest represents the mute friend request body
@@ -1,18 +1,128 @@
 package models
 
-import "time"
+import (
+	"sort"
+	"strings"
+	"time"
+)
 
-// History represents a notification trigger event
+// TriggerState represents how far a trigger has progressed on the receiver's device
+type TriggerState string
+
+const (
+	TriggerStateSent      TriggerState = "sent"
+	TriggerStateDelivered TriggerState = "delivered"
+	TriggerStateOpened    TriggerState = "opened"
+	TriggerStateResponded TriggerState = "responded"
+)
+
+// Rank returns the position of the state in the trigger lifecycle (higher = further along)
+func (s TriggerState) Rank() int {
+	switch s {
+	case TriggerStateDelivered:
+		return 1
+	case TriggerStateOpened:
+		return 2
+	case TriggerStateResponded:
+		return 3
+	default:
+		return 0
+	}
+}
+
+// HistoryType distinguishes triggers from replies to triggers
+type HistoryType string
+
+const (
+	HistoryTypeTrigger  HistoryType = "trigger"
+	HistoryTypeResponse HistoryType = "response"
+)
+
+// ResponseKind is the canned reply a receiver can send back to a trigger
+type ResponseKind string
+
+const (
+	ResponseAlive   ResponseKind = "alive"
+	ResponseBusy    ResponseKind = "busy"
+	ResponseOnMyWay ResponseKind = "on_my_way"
+)
+
+// Message returns the human readable text shown in the push notification
+func (k ResponseKind) Message() string {
+	switch k {
+	case ResponseAlive:
+		return "I'm back!"
+	case ResponseBusy:
+		return "Busy right now"
+	case ResponseOnMyWay:
+		return "On my way"
+	default:
+		return string(k)
+	}
+}
+
+// History represents a notification trigger event or a reply to one
 type History struct {
-	HistoryID      string    `firestore:"historyId" json:"historyId"`
-	SenderID       string    `firestore:"senderId" json:"senderId"`
-	ReceiverID     string    `firestore:"receiverId" json:"receiverId"`
-	SenderUsername string    `firestore:"senderUsername" json:"senderUsername"`
-	TriggeredAt    time.Time `firestore:"triggeredAt" json:"triggeredAt"`
+	HistoryID      string       `firestore:"historyId" json:"historyId"`
+	PairKey        string       `firestore:"pairKey" json:"-"`   // Canonical key shared by both directions of a friend pair
+	VisibleTo      []string     `firestore:"visibleTo" json:"-"` // Users who haven't deleted this record from their side
+	Type           HistoryType  `firestore:"type" json:"type"`
+	SenderID       string       `firestore:"senderId" json:"senderId"`
+	ReceiverID     string       `firestore:"receiverId" json:"receiverId"`
+	SenderUsername string       `firestore:"senderUsername" json:"senderUsername"`
+	TriggeredAt    time.Time    `firestore:"triggeredAt" json:"triggeredAt"`
+	State          TriggerState `firestore:"state" json:"state"`
+	DeliveredAt    *time.Time   `firestore:"deliveredAt,omitempty" json:"deliveredAt,omitempty"`
+	OpenedAt       *time.Time   `firestore:"openedAt,omitempty" json:"openedAt,omitempty"`
+	RespondedAt    *time.Time   `firestore:"respondedAt,omitempty" json:"respondedAt,omitempty"`
+	ResponseTo     string       `firestore:"responseTo,omitempty" json:"responseTo,omitempty"` // History ID of the trigger being answered
+	Response       ResponseKind `firestore:"response,omitempty" json:"response,omitempty"`
+	Read           bool         `firestore:"read" json:"read"` // Has the receiver seen this in their inbox?
+	ReadAt         *time.Time   `firestore:"readAt,omitempty" json:"readAt,omitempty"`
+}
+
+// VisibilityFor reports whether the record is still visible to userID and how many other users
+// can still see it. Once nobody else can, hiding it from userID means deleting it.
+func (h *History) VisibilityFor(userID string) (visible bool, others int) {
+	for _, id := range h.VisibleTo {
+		if id == userID {
+			visible = true
+		} else {
+			others++
+		}
+	}
+	return visible, others
+}
+
+// PairKey returns the canonical key for a pair of users, identical regardless of argument order
+func PairKey(userA, userB string) string {
+	ids := []string{userA, userB}
+	sort.Strings(ids)
+	return strings.Join(ids, ":")
 }
 
 // HistoryResponse represents the history listing response
 type HistoryResponse struct {
-	History []History `json:"history"`
-	Total   int       `json:"total"`
+	History    []*History `json:"history"`
+	NextCursor string     `json:"nextCursor,omitempty"`
+	Total      int        `json:"total"`
+}
+
+// InboxResponse represents a page of the current user's received notifications
+type InboxResponse struct {
+	Items       []*History `json:"items"`
+	NextCursor  string     `json:"nextCursor,omitempty"`
+	UnreadCount int        `json:"unreadCount"`
+}
+
+// MarkReadRequest represents the request to mark inbox entries as read.
+// An empty list marks everything as read.
+type MarkReadRequest struct {
+	HistoryIDs []string `json:"historyIds"`
+}
+
+// AckTriggerRequest represents the request body for acknowledging a received trigger.
+// A trigger only becomes responded when the receiver actually replies to it.
+type AckTriggerRequest struct {
+	State TriggerState `json:"state" binding:"required,oneof=delivered opened"`
 }
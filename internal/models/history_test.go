@@ -0,0 +1,36 @@
+package models
+
+import "testing"
+
+func TestHistoryVisibilityFor(t *testing.T) {
+	tests := []struct {
+		name      string
+		visibleTo []string
+		visible   bool
+		others    int
+	}{
+		{"both sides see it", []string{"alice", "bob"}, true, 1},
+		{"only the user is left", []string{"alice"}, true, 0},
+		{"already hidden by the user", []string{"bob"}, false, 1},
+		{"hidden by everyone", nil, false, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := &History{VisibleTo: tt.visibleTo}
+			visible, others := h.VisibilityFor("alice")
+			if visible != tt.visible || others != tt.others {
+				t.Fatalf("VisibilityFor = (%v, %d), expected (%v, %d)", visible, others, tt.visible, tt.others)
+			}
+		})
+	}
+}
+
+func TestPairKeyIgnoresOrder(t *testing.T) {
+	if PairKey("alice", "bob") != PairKey("bob", "alice") {
+		t.Fatal("PairKey must be the same in both directions")
+	}
+	if PairKey("alice", "bob") == PairKey("alice", "carol") {
+		t.Fatal("different pairs must get different keys")
+	}
+}
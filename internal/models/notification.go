@@ -10,6 +10,7 @@ type TriggerNotificationRequest struct {
 // TriggerNotificationResponse represents the successful trigger response
 type TriggerNotificationResponse struct {
 	Success         bool      `json:"success"`
+	HistoryID       string    `json:"historyId,omitempty"`
 	NextAvailableAt time.Time `json:"nextAvailableAt"`
 }
 
@@ -18,3 +19,16 @@ type TriggerErrorResponse struct {
 	Error       string     `json:"error"`
 	AvailableAt *time.Time `json:"availableAt,omitempty"`
 }
+
+// RespondTriggerRequest represents the request to reply to a received trigger
+type RespondTriggerRequest struct {
+	HistoryID string       `json:"historyId" binding:"required"`
+	Response  ResponseKind `json:"response" binding:"required,oneof=alive busy on_my_way"`
+}
+
+// RespondTriggerResponse represents the successful reply response
+type RespondTriggerResponse struct {
+	Success         bool      `json:"success"`
+	HistoryID       string    `json:"historyId"`
+	NextAvailableAt time.Time `json:"nextAvailableAt"`
+}
@@ -0,0 +1,115 @@
+package models
+
+import "time"
+
+// ReportCategory is why a user is being reported
+type ReportCategory string
+
+const (
+	ReportSpam                  ReportCategory = "spam"
+	ReportHarassment            ReportCategory = "harassment"
+	ReportInappropriateUsername ReportCategory = "inappropriate_username"
+	ReportInappropriateProfile  ReportCategory = "inappropriate_profile"
+	ReportImpersonation         ReportCategory = "impersonation"
+	ReportOther                 ReportCategory = "other"
+)
+
+// ReportStatus is where a report is in the moderation queue
+type ReportStatus string
+
+const (
+	ReportOpen      ReportStatus = "open"
+	ReportInReview  ReportStatus = "in_review" // Claimed by a moderator
+	ReportResolved  ReportStatus = "resolved"  // Action was taken
+	ReportDismissed ReportStatus = "dismissed" // No action needed
+)
+
+// Pending reports whether the report is still waiting for a moderator's decision
+func (s ReportStatus) Pending() bool {
+	return s == ReportOpen || s == ReportInReview
+}
+
+// ModerationAction is what a moderator did about a report
+type ModerationAction string
+
+const (
+	ModerationDismiss ModerationAction = "dismiss"
+	ModerationWarn    ModerationAction = "warn" // Noted on the report; no restriction
+	ModerationSuspend ModerationAction = "suspend"
+	ModerationBan     ModerationAction = "ban"
+)
+
+// ReportSnapshot preserves the reported content as it was when the report was filed,
+// so renames, profile edits and deleted history can't erase the evidence
+type ReportSnapshot struct {
+	Username    string     `firestore:"username" json:"username"`
+	DisplayName string     `firestore:"displayName,omitempty" json:"displayName,omitempty"`
+	Bio         string     `firestore:"bio,omitempty" json:"bio,omitempty"`
+	AvatarURL   string     `firestore:"avatarUrl,omitempty" json:"avatarUrl,omitempty"`
+	History     []*History `firestore:"history,omitempty" json:"history,omitempty"`
+}
+
+// Report is a user's report about another user (reports/{reportId})
+type Report struct {
+	ReportID       string           `firestore:"reportId" json:"reportId"`
+	ReporterID     string           `firestore:"reporterId" json:"reporterId"`
+	ReportedUserID string           `firestore:"reportedUserId" json:"reportedUserId"`
+	Category       ReportCategory   `firestore:"category" json:"category"`
+	Details        string           `firestore:"details,omitempty" json:"details,omitempty"`
+	HistoryIDs     []string         `firestore:"historyIds,omitempty" json:"historyIds,omitempty"`
+	Snapshot       ReportSnapshot   `firestore:"snapshot" json:"snapshot"`
+	Status         ReportStatus     `firestore:"status" json:"status"`
+	CreatedAt      time.Time        `firestore:"createdAt" json:"createdAt"`
+	ClaimedBy      string           `firestore:"claimedBy,omitempty" json:"claimedBy,omitempty"`
+	ClaimedAt      *time.Time       `firestore:"claimedAt,omitempty" json:"claimedAt,omitempty"`
+	Action         ModerationAction `firestore:"action,omitempty" json:"action,omitempty"`
+	ResolutionNote string           `firestore:"resolutionNote,omitempty" json:"resolutionNote,omitempty"`
+	ResolvedBy     string           `firestore:"resolvedBy,omitempty" json:"resolvedBy,omitempty"`
+	ResolvedAt     *time.Time       `firestore:"resolvedAt,omitempty" json:"resolvedAt,omitempty"`
+}
+
+// PendingReporters counts the distinct users behind the reports that are still pending.
+// Reports a moderator has already dismissed or acted on don't count again.
+func PendingReporters(reports []*Report) int {
+	reporters := map[string]bool{}
+	for _, report := range reports {
+		if report.Status.Pending() {
+			reporters[report.ReporterID] = true
+		}
+	}
+	return len(reporters)
+}
+
+// ReportUserRequest represents the request body for reporting a user
+type ReportUserRequest struct {
+	ReportedUserID string         `json:"reportedUserId" binding:"required"`
+	Category       ReportCategory `json:"category" binding:"required,oneof=spam harassment inappropriate_username inappropriate_profile impersonation other"`
+	Details        string         `json:"details" binding:"max=1000"`
+	HistoryIDs     []string       `json:"historyIds" binding:"max=20"`
+}
+
+// ResolveReportRequest represents a moderator's decision on a report
+type ResolveReportRequest struct {
+	Action        ModerationAction `json:"action" binding:"required,oneof=dismiss warn suspend ban"`
+	Note          string           `json:"note" binding:"max=1000"`
+	SuspendHours  int              `json:"suspendHours" binding:"omitempty,min=1,max=8760"` // Suspension length; 0 means until lifted by hand
+	ReasonForUser string           `json:"reasonForUser" binding:"max=500"`                 // Shown to the suspended or banned user
+}
+
+// ReporterReportView is what reporters see of their own reports
+type ReporterReportView struct {
+	ReportID       string         `json:"reportId"`
+	ReportedUserID string         `json:"reportedUserId"`
+	Username       string         `json:"username"` // Reported user's name at report time
+	Category       ReportCategory `json:"category"`
+	Status         ReportStatus   `json:"status"`
+	ActionTaken    bool           `json:"actionTaken"` // Whether the report led to a warning or restriction
+	CreatedAt      time.Time      `json:"createdAt"`
+	ResolvedAt     *time.Time     `json:"resolvedAt,omitempty"`
+}
+
+// ReportQueueResponse represents a page of the moderation queue
+type ReportQueueResponse struct {
+	Reports    []*Report `json:"reports"`
+	NextCursor string    `json:"nextCursor,omitempty"`
+}
@@ -0,0 +1,34 @@
+package models
+
+import "testing"
+
+func TestPendingReporters(t *testing.T) {
+	report := func(reporterID string, status ReportStatus) *Report {
+		return &Report{ReporterID: reporterID, Status: status}
+	}
+
+	tests := []struct {
+		name    string
+		reports []*Report
+		want    int
+	}{
+		{"no reports", nil, 0},
+		{"open and claimed reports count", []*Report{report("a", ReportOpen), report("b", ReportInReview)}, 2},
+		{"same reporter counts once", []*Report{report("a", ReportOpen), report("a", ReportInReview)}, 1},
+		{"decided reports don't count", []*Report{report("a", ReportDismissed), report("b", ReportResolved)}, 0},
+		{
+			"only reports after the decision count",
+			[]*Report{report("a", ReportDismissed), report("b", ReportDismissed), report("c", ReportOpen)},
+			1,
+		},
+		{"reporter with a dismissed and a new report", []*Report{report("a", ReportDismissed), report("a", ReportOpen)}, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := PendingReporters(tt.reports); got != tt.want {
+				t.Fatalf("PendingReporters = %d, expected %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,186 @@
+package models
+
+import (
+	"sort"
+	"time"
+)
+
+// statsDayFormat is the key format for daily buckets (UTC)
+const statsDayFormat = "2006-01-02"
+
+// statsRetentionDays is how many daily buckets are kept on an aggregate
+const statsRetentionDays = 60
+
+// DailyCount holds trigger counts for a single day
+type DailyCount struct {
+	Sent     int `firestore:"sent" json:"sent"`
+	Received int `firestore:"received" json:"received"`
+}
+
+// TriggerStats is an incrementally maintained aggregate of trigger activity.
+// One exists per user (stats/{userId}) and one per user per friend (stats/{userId}/friends/{friendId}).
+// An active day is a day with at least one trigger sent or received.
+type TriggerStats struct {
+	TotalSent            int                   `firestore:"totalSent"`
+	TotalReceived        int                   `firestore:"totalReceived"`
+	Days                 map[string]DailyCount `firestore:"days"` // "2006-01-02" -> counts, last 60 days only
+	CurrentStreak        int                   `firestore:"currentStreak"`
+	LongestStreak        int                   `firestore:"longestStreak"`
+	LastActiveDay        string                `firestore:"lastActiveDay"`
+	ResponseCount        int                   `firestore:"responseCount"`        // Triggers this user answered
+	ResponseTotalSeconds int64                 `firestore:"responseTotalSeconds"` // Sum of time taken to answer them
+	Friends              map[string]int        `firestore:"friends,omitempty"`    // friendId -> triggers exchanged (per-user aggregate only)
+	UpdatedAt            time.Time             `firestore:"updatedAt"`
+}
+
+// AddTrigger records a trigger sent (or received) at the given time
+func (s *TriggerStats) AddTrigger(at time.Time, sent bool, friendID string) {
+	day := at.UTC().Format(statsDayFormat)
+
+	if s.Days == nil {
+		s.Days = map[string]DailyCount{}
+	}
+	counts := s.Days[day]
+	if sent {
+		counts.Sent++
+		s.TotalSent++
+	} else {
+		counts.Received++
+		s.TotalReceived++
+	}
+	s.Days[day] = counts
+
+	if friendID != "" {
+		if s.Friends == nil {
+			s.Friends = map[string]int{}
+		}
+		s.Friends[friendID]++
+	}
+
+	// Update streaks (days are ISO formatted so string comparison orders them)
+	switch {
+	case s.LastActiveDay == day:
+	case s.LastActiveDay == at.UTC().AddDate(0, 0, -1).Format(statsDayFormat):
+		s.CurrentStreak++
+		s.LastActiveDay = day
+	case s.LastActiveDay < day:
+		s.CurrentStreak = 1
+		s.LastActiveDay = day
+	}
+	if s.CurrentStreak > s.LongestStreak {
+		s.LongestStreak = s.CurrentStreak
+	}
+
+	// Drop buckets older than the retention window
+	cutoff := at.UTC().AddDate(0, 0, -statsRetentionDays).Format(statsDayFormat)
+	for d := range s.Days {
+		if d < cutoff {
+			delete(s.Days, d)
+		}
+	}
+
+	s.UpdatedAt = time.Now()
+}
+
+// AddResponse records how long this user took to answer a trigger
+func (s *TriggerStats) AddResponse(took time.Duration) {
+	if took < 0 {
+		took = 0
+	}
+	s.ResponseCount++
+	s.ResponseTotalSeconds += int64(took.Seconds())
+	s.UpdatedAt = time.Now()
+}
+
+// Summary builds the API view of the aggregate as of now
+func (s *TriggerStats) Summary(now time.Time) *StatsSummary {
+	summary := &StatsSummary{
+		TotalSent:     s.TotalSent,
+		TotalReceived: s.TotalReceived,
+		LongestStreak: s.LongestStreak,
+	}
+
+	today := now.UTC()
+	for i := 0; i < 30; i++ {
+		counts := s.Days[today.AddDate(0, 0, -i).Format(statsDayFormat)]
+		if i == 0 {
+			summary.Today.add(counts)
+		}
+		if i < 7 {
+			summary.Week.add(counts)
+		}
+		summary.Month.add(counts)
+	}
+
+	// The current streak only survives if the user was active today or yesterday
+	if s.LastActiveDay == today.Format(statsDayFormat) || s.LastActiveDay == today.AddDate(0, 0, -1).Format(statsDayFormat) {
+		summary.CurrentStreak = s.CurrentStreak
+	}
+
+	if s.ResponseCount > 0 {
+		avg := float64(s.ResponseTotalSeconds) / float64(s.ResponseCount)
+		summary.AverageResponseSeconds = &avg
+	}
+
+	return summary
+}
+
+// TopFriendIDs returns up to n friend IDs ordered by triggers exchanged
+func (s *TriggerStats) TopFriendIDs(n int) []string {
+	ids := make([]string, 0, len(s.Friends))
+	for id := range s.Friends {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		if s.Friends[ids[i]] != s.Friends[ids[j]] {
+			return s.Friends[ids[i]] > s.Friends[ids[j]]
+		}
+		return ids[i] < ids[j]
+	})
+	if len(ids) > n {
+		ids = ids[:n]
+	}
+	return ids
+}
+
+// PeriodCounts holds trigger counts over a time window
+type PeriodCounts struct {
+	Sent     int `json:"sent"`
+	Received int `json:"received"`
+}
+
+func (p *PeriodCounts) add(counts DailyCount) {
+	p.Sent += counts.Sent
+	p.Received += counts.Received
+}
+
+// StatsSummary represents trigger statistics returned by the API
+type StatsSummary struct {
+	Today                  PeriodCounts `json:"today"`
+	Week                   PeriodCounts `json:"week"`  // Last 7 days including today
+	Month                  PeriodCounts `json:"month"` // Last 30 days including today
+	TotalSent              int          `json:"totalSent"`
+	TotalReceived          int          `json:"totalReceived"`
+	CurrentStreak          int          `json:"currentStreak"` // Consecutive active days up to today or yesterday
+	LongestStreak          int          `json:"longestStreak"`
+	AverageResponseSeconds *float64     `json:"averageResponseSeconds,omitempty"`
+}
+
+// TopFriend represents a friend ranked by triggers exchanged
+type TopFriend struct {
+	UserID   string `json:"userId"`
+	Username string `json:"username"`
+	Count    int    `json:"count"`
+}
+
+// UserStatsResponse represents the current user's overall statistics
+type UserStatsResponse struct {
+	StatsSummary
+	TopFriends []*TopFriend `json:"topFriends"`
+}
+
+// FriendStatsResponse represents statistics between the current user and one friend
+type FriendStatsResponse struct {
+	StatsSummary
+	FriendUserID string `json:"friendUserId"`
+}
@@ -4,18 +4,72 @@ import "time"
 
 // User represents a user in the system
 type User struct {
-	UserID       string    `firestore:"userId" json:"userId"`
-	Username     string    `firestore:"username" json:"username"`
-	PasswordHash string    `firestore:"passwordHash" json:"-"` // Don't expose in JSON
-	FCMToken     string    `firestore:"fcmToken" json:"fcmToken,omitempty"`
-	CreatedAt    time.Time `firestore:"createdAt" json:"createdAt"`
-	MutedAll     bool      `firestore:"mutedAll" json:"mutedAll"`
+	UserID             string    `firestore:"userId" json:"userId"`
+	Username           string    `firestore:"username" json:"username"`
+	UsernameLower      string    `firestore:"usernameLower" json:"-"`                // Normalized for prefix search and uniqueness
+	PasswordHash       string    `firestore:"passwordHash" json:"-"`                 // Don't expose in JSON
+	RecoveryCodeHashes []string  `firestore:"recoveryCodeHashes,omitempty" json:"-"` // SHA-256 of unused one-time recovery codes
+	FCMToken           string    `firestore:"fcmToken" json:"fcmToken,omitempty"`
+	CreatedAt          time.Time `firestore:"createdAt" json:"createdAt"`
+	MutedAll           bool      `firestore:"mutedAll" json:"mutedAll"`
+	Role               Role      `firestore:"role,omitempty" json:"role,omitempty"` // Empty means RoleUser
+
+	// Moderation
+	Status          AccountStatus `firestore:"status,omitempty" json:"status,omitempty"` // Empty means AccountActive
+	StatusReason    string        `firestore:"statusReason,omitempty" json:"statusReason,omitempty"`
+	StatusUntil     *time.Time    `firestore:"statusUntil,omitempty" json:"statusUntil,omitempty"`
+	StatusChangedAt *time.Time    `firestore:"statusChangedAt,omitempty" json:"statusChangedAt,omitempty"`
+	StatusChangedBy string        `firestore:"statusChangedBy,omitempty" json:"statusChangedBy,omitempty"`
+
+	UsernameChangedAt    *time.Time `firestore:"usernameChangedAt,omitempty" json:"usernameChangedAt,omitempty"`
+	DeletionScheduledFor *time.Time `firestore:"deletionScheduledFor,omitempty" json:"deletionScheduledFor,omitempty"` // Pending account deletion
+
+	// Profile
+	DisplayName    string `firestore:"displayName,omitempty" json:"displayName,omitempty"`
+	Bio            string `firestore:"bio,omitempty" json:"bio,omitempty"`
+	AvatarURL      string `firestore:"avatarUrl,omitempty" json:"avatarUrl,omitempty"`           // 256px thumbnail
+	AvatarThumbURL string `firestore:"avatarThumbUrl,omitempty" json:"avatarThumbUrl,omitempty"` // 64px thumbnail
+	AvatarKey      string `firestore:"avatarKey,omitempty" json:"-"`                             // Blob key prefix of the current avatar
+}
+
+// EffectiveRole returns the user's role, defaulting to RoleUser
+func (u *User) EffectiveRole() Role {
+	if u.Role == "" {
+		return RoleUser
+	}
+	return u.Role
+}
+
+// EffectiveStatus returns the user's account status, defaulting to AccountActive
+func (u *User) EffectiveStatus() AccountStatus {
+	if u.Status == "" {
+		return AccountActive
+	}
+	return u.Status
+}
+
+// Restriction returns the suspension or ban in effect at now, or nil if the user may use the service.
+// A suspension past its end no longer applies even before the sweep marks the user active again.
+func (u *User) Restriction(now time.Time) *AccountRestriction {
+	status := u.EffectiveStatus()
+	if status == AccountActive {
+		return nil
+	}
+	if u.StatusUntil != nil && !now.Before(*u.StatusUntil) {
+		return nil
+	}
+	return &AccountRestriction{Status: status, Reason: u.StatusReason, Until: u.StatusUntil}
+}
+
+// Searchable reports whether the user should appear in user search
+func (u *User) Searchable(now time.Time) bool {
+	return u.Restriction(now) == nil && u.DeletionScheduledFor == nil
 }
 
 // RegisterRequest represents the registration request body
 type RegisterRequest struct {
 	Username string `json:"username" binding:"required,min=3,max=16"`
-	Password string `json:"password" binding:"required,min=6"`
+	Password string `json:"password" binding:"required"` // Checked against the password policy
 }
 
 // LoginRequest represents the login request body
@@ -26,12 +80,50 @@ type LoginRequest struct {
 
 // AuthResponse represents the authentication response
 type AuthResponse struct {
-	UserID   string `json:"userId"`
-	Username string `json:"username"`
-	Token    string `json:"token"`
+	UserID            string   `json:"userId"`
+	Username          string   `json:"username"`
+	Token             string   `json:"token"`
+	RecoveryCodes     []string `json:"recoveryCodes,omitempty"`     // Only returned at registration
+	DeletionCancelled bool     `json:"deletionCancelled,omitempty"` // Logging in cancelled a pending account deletion
+}
+
+// ChangePasswordRequest represents the change password request body
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"currentPassword" binding:"required"`
+	NewPassword     string `json:"newPassword" binding:"required"`
+}
+
+// ResetPasswordRequest represents the password reset request body (using a one-time recovery code)
+type ResetPasswordRequest struct {
+	Username     string `json:"username" binding:"required"`
+	RecoveryCode string `json:"recoveryCode" binding:"required"`
+	NewPassword  string `json:"newPassword" binding:"required"`
+}
+
+// RegenerateRecoveryCodesRequest represents the request to replace the user's recovery codes
+type RegenerateRecoveryCodesRequest struct {
+	Password string `json:"password" binding:"required"`
 }
 
 // UpdateFCMTokenRequest represents the FCM token update request
 type UpdateFCMTokenRequest struct {
 	FCMToken string `json:"fcmToken" binding:"required"`
 }
+
+// UserProfile represents a user's public profile
+type UserProfile struct {
+	UserID         string    `json:"userId"`
+	Username       string    `json:"username"`
+	DisplayName    string    `json:"displayName,omitempty"`
+	Bio            string    `json:"bio,omitempty"`
+	AvatarURL      string    `json:"avatarUrl,omitempty"`
+	AvatarThumbURL string    `json:"avatarThumbUrl,omitempty"`
+	CreatedAt      time.Time `json:"createdAt"`
+	FriendCount    int       `json:"friendCount"`
+}
+
+// UpdateProfileRequest represents a partial profile update; omitted fields are left unchanged
+type UpdateProfileRequest struct {
+	DisplayName *string `json:"displayName" binding:"omitempty,max=32"`
+	Bio         *string `json:"bio" binding:"omitempty,max=160"`
+}
@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+// UsernameReservation claims a lowercased username for a single user.
+// Stored in the usernames collection keyed by the lowercased name.
+// After a rename the old name stays held for its previous owner until HeldUntil.
+type UsernameReservation struct {
+	UsernameLower string     `firestore:"usernameLower" json:"usernameLower"`
+	UserID        string     `firestore:"userId" json:"userId"`
+	ReservedAt    time.Time  `firestore:"reservedAt" json:"reservedAt"`
+	ReleasedAt    *time.Time `firestore:"releasedAt,omitempty" json:"releasedAt,omitempty"`
+	HeldUntil     *time.Time `firestore:"heldUntil,omitempty" json:"heldUntil,omitempty"`
+}
+
+// AvailableTo reports whether the reserved name can be claimed by the given user at the given time
+func (r *UsernameReservation) AvailableTo(userID string, now time.Time) bool {
+	if r.UserID == userID {
+		return true
+	}
+	return r.HeldUntil != nil && now.After(*r.HeldUntil)
+}
+
+// UsernameChange records a rename in users/{userId}/usernameHistory
+type UsernameChange struct {
+	OldUsername string    `firestore:"oldUsername" json:"oldUsername"`
+	NewUsername string    `firestore:"newUsername" json:"newUsername"`
+	ChangedAt   time.Time `firestore:"changedAt" json:"changedAt"`
+}
+
+// ChangeUsernameRequest represents the request to change the current user's username
+type ChangeUsernameRequest struct {
+	Username string `json:"username" binding:"required"`
+}
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestUsernameReservationAvailableTo(t *testing.T) {
+	now := time.Now()
+	before := now.Add(-time.Minute)
+	after := now.Add(time.Minute)
+
+	tests := []struct {
+		name        string
+		reservation UsernameReservation
+		userID      string
+		available   bool
+	}{
+		{"own reservation", UsernameReservation{UserID: "alice"}, "alice", true},
+		{"own held name", UsernameReservation{UserID: "alice", HeldUntil: &after}, "alice", true},
+		{"someone else's name", UsernameReservation{UserID: "alice"}, "bob", false},
+		{"still held", UsernameReservation{UserID: "alice", HeldUntil: &after}, "bob", false},
+		{"hold expired", UsernameReservation{UserID: "alice", HeldUntil: &before}, "bob", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.reservation.AvailableTo(tt.userID, now); got != tt.available {
+				t.Fatalf("AvailableTo(%q) = %v, expected %v", tt.userID, got, tt.available)
+			}
+		})
+	}
+}
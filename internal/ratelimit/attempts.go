@@ -0,0 +1,93 @@
+package ratelimit
+
+import (
+	"context"
+	"time"
+)
+
+// AttemptPolicy controls when repeated attempts lead to a lockout
+type AttemptPolicy struct {
+	Threshold   int           // Attempts allowed within Window before locking out
+	Window      time.Duration // Attempts older than this are forgotten (unless locked out)
+	BaseLockout time.Duration // Lockout after hitting the threshold; doubles with every further attempt
+	MaxLockout  time.Duration // Upper bound for the lockout
+}
+
+// AttemptState is the tracked state for one key
+type AttemptState struct {
+	Attempts    int       `firestore:"attempts"`
+	WindowStart time.Time `firestore:"windowStart"`
+	LockedUntil time.Time `firestore:"lockedUntil"`
+	ExpiresAt   time.Time `firestore:"expiresAt"` // After this the state has no effect and may be deleted (Firestore TTL)
+}
+
+// AttemptStore persists attempt state. Update must apply fn atomically.
+type AttemptStore interface {
+	Get(ctx context.Context, key string) (*AttemptState, error)
+	Update(ctx context.Context, key string, fn func(state *AttemptState)) (*AttemptState, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// AttemptLimiter counts attempts per key (IP, username, ...) and locks keys out with exponential backoff
+type AttemptLimiter struct {
+	store  AttemptStore
+	policy AttemptPolicy
+}
+
+// NewAttemptLimiter creates a limiter with the given store and policy
+func NewAttemptLimiter(store AttemptStore, policy AttemptPolicy) *AttemptLimiter {
+	return &AttemptLimiter{store: store, policy: policy}
+}
+
+// Check returns how long the key is still locked out for (0 if it isn't)
+func (l *AttemptLimiter) Check(ctx context.Context, key string) (time.Duration, error) {
+	state, err := l.store.Get(ctx, key)
+	if err != nil || state == nil {
+		return 0, err
+	}
+	return remaining(state.LockedUntil), nil
+}
+
+// Record counts an attempt and returns the lockout now in effect (0 if none)
+func (l *AttemptLimiter) Record(ctx context.Context, key string) (time.Duration, error) {
+	state, err := l.store.Update(ctx, key, func(state *AttemptState) {
+		now := time.Now()
+		if now.Sub(state.WindowStart) > l.policy.Window && now.After(state.LockedUntil) {
+			state.Attempts = 0
+			state.WindowStart = now
+		}
+		state.Attempts++
+
+		if state.Attempts >= l.policy.Threshold {
+			lockout := l.policy.BaseLockout
+			for i := l.policy.Threshold; i < state.Attempts && lockout < l.policy.MaxLockout; i++ {
+				lockout *= 2
+			}
+			if lockout > l.policy.MaxLockout {
+				lockout = l.policy.MaxLockout
+			}
+			state.LockedUntil = now.Add(lockout)
+		}
+
+		state.ExpiresAt = state.WindowStart.Add(l.policy.Window)
+		if state.LockedUntil.After(state.ExpiresAt) {
+			state.ExpiresAt = state.LockedUntil
+		}
+	})
+	if err != nil {
+		return 0, err
+	}
+	return remaining(state.LockedUntil), nil
+}
+
+// Reset forgets a key (e.g. after a successful login)
+func (l *AttemptLimiter) Reset(ctx context.Context, key string) error {
+	return l.store.Delete(ctx, key)
+}
+
+func remaining(until time.Time) time.Duration {
+	if d := time.Until(until); d > 0 {
+		return d
+	}
+	return 0
+}
@@ -0,0 +1,99 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Rate is a token bucket policy: up to Limit requests in a burst, refilled evenly over Period
+type Rate struct {
+	Limit  int
+	Period time.Duration
+}
+
+// String formats the rate as "limit/period" (the format ParseRate accepts)
+func (r Rate) String() string {
+	return fmt.Sprintf("%d/%s", r.Limit, r.Period)
+}
+
+// ParseRate parses "limit/period", e.g. "30/1m" or "600/1h"
+func ParseRate(s string) (Rate, error) {
+	limitStr, period, ok := strings.Cut(strings.TrimSpace(s), "/")
+	if !ok {
+		return Rate{}, fmt.Errorf("invalid rate %q: expected limit/period", s)
+	}
+	limit, err := strconv.Atoi(limitStr)
+	if err != nil {
+		return Rate{}, fmt.Errorf("invalid rate %q: %w", s, err)
+	}
+	d, err := time.ParseDuration(period)
+	if err != nil {
+		return Rate{}, fmt.Errorf("invalid rate %q: %w", s, err)
+	}
+	if limit <= 0 || d <= 0 {
+		return Rate{}, fmt.Errorf("invalid rate %q: limit and period must be positive", s)
+	}
+	return Rate{Limit: limit, Period: d}, nil
+}
+
+// BucketState is the stored state of one token bucket
+type BucketState struct {
+	Tokens    float64   `firestore:"tokens"`
+	UpdatedAt time.Time `firestore:"updatedAt"`
+	ExpiresAt time.Time `firestore:"expiresAt"` // When the bucket is full again; a missing bucket is the same (Firestore TTL)
+}
+
+// BucketResult describes the outcome of taking a token
+type BucketResult struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int           // Whole tokens left after this request
+	Reset      time.Duration // Time until the bucket is full again
+	RetryAfter time.Duration // Time until the next token is available (0 if allowed)
+}
+
+// BucketStore persists token buckets. Update must apply fn atomically.
+type BucketStore interface {
+	Update(ctx context.Context, key string, fn func(state *BucketState)) (*BucketState, error)
+}
+
+// Take removes one token from the bucket for key, refilling it for the time elapsed since the last request
+func Take(ctx context.Context, store BucketStore, key string, rate Rate) (*BucketResult, error) {
+	perToken := rate.Period / time.Duration(rate.Limit)
+	capacity := float64(rate.Limit)
+	allowed := false
+
+	state, err := store.Update(ctx, key, func(state *BucketState) {
+		now := time.Now()
+		if state.UpdatedAt.IsZero() {
+			state.Tokens = capacity
+		} else if elapsed := now.Sub(state.UpdatedAt); elapsed > 0 {
+			state.Tokens = math.Min(capacity, state.Tokens+float64(elapsed)/float64(perToken))
+		}
+		state.UpdatedAt = now
+
+		allowed = state.Tokens >= 1
+		if allowed {
+			state.Tokens--
+		}
+		state.ExpiresAt = now.Add(time.Duration((capacity - state.Tokens) * float64(perToken)))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := &BucketResult{
+		Allowed:   allowed,
+		Limit:     rate.Limit,
+		Remaining: int(state.Tokens),
+		Reset:     time.Duration((capacity - state.Tokens) * float64(perToken)),
+	}
+	if !allowed {
+		result.RetryAfter = time.Duration((1 - state.Tokens) * float64(perToken))
+	}
+	return result, nil
+}
@@ -0,0 +1,46 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestAttemptStateExpiresAfterWindowOrLockout(t *testing.T) {
+	ctx := context.Background()
+	limiter := NewAttemptLimiter(NewMemoryAttemptStore(time.Hour), AttemptPolicy{
+		Threshold:   2,
+		Window:      10 * time.Minute,
+		BaseLockout: time.Hour,
+		MaxLockout:  4 * time.Hour,
+	})
+
+	limiter.Record(ctx, "k")
+	state, _ := limiter.store.Get(ctx, "k")
+	if want := state.WindowStart.Add(10 * time.Minute); !state.ExpiresAt.Equal(want) {
+		t.Fatalf("before a lockout ExpiresAt = %s, expected the end of the window %s", state.ExpiresAt, want)
+	}
+
+	limiter.Record(ctx, "k")
+	state, _ = limiter.store.Get(ctx, "k")
+	if !state.ExpiresAt.Equal(state.LockedUntil) {
+		t.Fatalf("during a lockout ExpiresAt = %s, expected the lockout end %s", state.ExpiresAt, state.LockedUntil)
+	}
+}
+
+func TestBucketExpiresWhenFull(t *testing.T) {
+	store := NewMemoryBucketStore(time.Hour)
+	rate := Rate{Limit: 10, Period: 10 * time.Minute}
+
+	result, err := Take(context.Background(), store, "k", rate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	state, err := store.Update(context.Background(), "k", func(*BucketState) {})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := state.ExpiresAt.Sub(state.UpdatedAt); got != result.Reset {
+		t.Fatalf("bucket expires %s after its last update, expected %s (when it's full again)", got, result.Reset)
+	}
+}
@@ -0,0 +1,83 @@
+package ratelimit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// FirestoreAttemptStore keeps attempt state in Firestore so every instance shares it.
+// Idle documents are deleted by a TTL policy on expiresAt (see firestore.indexes.json).
+type FirestoreAttemptStore struct {
+	client     *firestore.Client
+	collection string
+}
+
+// NewFirestoreAttemptStore creates a store backed by the given collection
+func NewFirestoreAttemptStore(client *firestore.Client, collection string) *FirestoreAttemptStore {
+	return &FirestoreAttemptStore{client: client, collection: collection}
+}
+
+// Get returns the state for key (nil if unknown)
+func (s *FirestoreAttemptStore) Get(ctx context.Context, key string) (*AttemptState, error) {
+	doc, err := s.ref(key).Get(ctx)
+	if status.Code(err) == codes.NotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var state AttemptState
+	if err := doc.DataTo(&state); err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+// Update applies fn to the state for key inside a transaction
+func (s *FirestoreAttemptStore) Update(ctx context.Context, key string, fn func(state *AttemptState)) (*AttemptState, error) {
+	ref := s.ref(key)
+
+	var state AttemptState
+	err := s.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		state = AttemptState{}
+
+		doc, err := tx.Get(ref)
+		if err != nil && status.Code(err) != codes.NotFound {
+			return err
+		}
+		if err == nil {
+			if err := doc.DataTo(&state); err != nil {
+				return err
+			}
+		}
+
+		fn(&state)
+		return tx.Set(ref, state)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+// Delete forgets key
+func (s *FirestoreAttemptStore) Delete(ctx context.Context, key string) error {
+	_, err := s.ref(key).Delete(ctx)
+	return err
+}
+
+func (s *FirestoreAttemptStore) ref(key string) *firestore.DocumentRef {
+	return s.client.Collection(s.collection).Doc(hashKey(key))
+}
+
+// hashKey hashes a key so usernames and IPs aren't stored in document IDs
+func hashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,48 @@
+package ratelimit
+
+import (
+	"context"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// FirestoreBucketStore keeps token buckets in Firestore so every instance shares them.
+// Idle documents are deleted by a TTL policy on expiresAt (see firestore.indexes.json).
+type FirestoreBucketStore struct {
+	client     *firestore.Client
+	collection string
+}
+
+// NewFirestoreBucketStore creates a store backed by the given collection
+func NewFirestoreBucketStore(client *firestore.Client, collection string) *FirestoreBucketStore {
+	return &FirestoreBucketStore{client: client, collection: collection}
+}
+
+// Update applies fn to the bucket for key inside a transaction
+func (s *FirestoreBucketStore) Update(ctx context.Context, key string, fn func(state *BucketState)) (*BucketState, error) {
+	ref := s.client.Collection(s.collection).Doc(hashKey(key))
+
+	var state BucketState
+	err := s.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		state = BucketState{}
+
+		doc, err := tx.Get(ref)
+		if err != nil && status.Code(err) != codes.NotFound {
+			return err
+		}
+		if err == nil {
+			if err := doc.DataTo(&state); err != nil {
+				return err
+			}
+		}
+
+		fn(&state)
+		return tx.Set(ref, state)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
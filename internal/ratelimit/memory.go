@@ -0,0 +1,79 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryAttemptStore keeps attempt state in process memory (single instance only)
+type MemoryAttemptStore struct {
+	mu     sync.Mutex
+	states map[string]*AttemptState
+	maxAge time.Duration
+	pruned time.Time
+}
+
+// attemptPruneInterval bounds how often Update scans for idle keys, so many keys don't make every attempt slow
+const attemptPruneInterval = time.Minute
+
+// NewMemoryAttemptStore creates an in-memory store that forgets idle keys after maxAge
+func NewMemoryAttemptStore(maxAge time.Duration) *MemoryAttemptStore {
+	return &MemoryAttemptStore{
+		states: make(map[string]*AttemptState),
+		maxAge: maxAge,
+	}
+}
+
+// Get returns a copy of the state for key (nil if unknown)
+func (s *MemoryAttemptStore) Get(ctx context.Context, key string) (*AttemptState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	state, ok := s.states[key]
+	if !ok {
+		return nil, nil
+	}
+	copied := *state
+	return &copied, nil
+}
+
+// Update applies fn to the state for key under the store lock
+func (s *MemoryAttemptStore) Update(ctx context.Context, key string, fn func(state *AttemptState)) (*AttemptState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.pruneLocked()
+
+	state, ok := s.states[key]
+	if !ok {
+		state = &AttemptState{}
+		s.states[key] = state
+	}
+	fn(state)
+
+	copied := *state
+	return &copied, nil
+}
+
+// Delete forgets key
+func (s *MemoryAttemptStore) Delete(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.states, key)
+	return nil
+}
+
+// pruneLocked drops keys that are neither locked nor recently active, at most once per attemptPruneInterval
+func (s *MemoryAttemptStore) pruneLocked() {
+	now := time.Now()
+	if now.Sub(s.pruned) < attemptPruneInterval {
+		return
+	}
+	s.pruned = now
+
+	for key, state := range s.states {
+		if now.After(state.LockedUntil) && now.Sub(state.WindowStart) > s.maxAge {
+			delete(s.states, key)
+		}
+	}
+}
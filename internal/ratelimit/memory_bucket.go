@@ -0,0 +1,57 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryBucketStore keeps token buckets in process memory (single instance only)
+type MemoryBucketStore struct {
+	mu      sync.Mutex
+	buckets map[string]*BucketState
+	maxIdle time.Duration
+	pruned  time.Time
+}
+
+// NewMemoryBucketStore creates an in-memory store that forgets buckets idle for longer than maxIdle.
+// maxIdle should be at least the longest rate period, by which time an idle bucket is full anyway.
+func NewMemoryBucketStore(maxIdle time.Duration) *MemoryBucketStore {
+	return &MemoryBucketStore{
+		buckets: make(map[string]*BucketState),
+		maxIdle: maxIdle,
+	}
+}
+
+// Update applies fn to the bucket for key under the store lock
+func (s *MemoryBucketStore) Update(ctx context.Context, key string, fn func(state *BucketState)) (*BucketState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.pruneLocked()
+
+	state, ok := s.buckets[key]
+	if !ok {
+		state = &BucketState{}
+		s.buckets[key] = state
+	}
+	fn(state)
+
+	copied := *state
+	return &copied, nil
+}
+
+// pruneLocked drops idle buckets, at most once per maxIdle since every request passes through here
+func (s *MemoryBucketStore) pruneLocked() {
+	now := time.Now()
+	if now.Sub(s.pruned) < s.maxIdle {
+		return
+	}
+	s.pruned = now
+
+	for key, state := range s.buckets {
+		if now.Sub(state.UpdatedAt) > s.maxIdle {
+			delete(s.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/yourusername/rbd-service/internal/config"
+	"github.com/yourusername/rbd-service/internal/models"
+	"google.golang.org/api/iterator"
+)
+
+// AuditRepository appends to and reads the auditLog collection.
+// Entries are only updated to anonymize a deleted user, and deleted once they fall out of the retention window.
+type AuditRepository struct {
+	client *firestore.Client
+}
+
+func NewAuditRepository() *AuditRepository {
+	return &AuditRepository{
+		client: config.FirestoreClient,
+	}
+}
+
+// Record appends an entry to the audit log
+func (r *AuditRepository) Record(ctx context.Context, entry *models.AuditEntry) error {
+	ctx, op := observe(ctx, "audit.Record")
+	defer op.End()
+
+	// Tools and tests run without Firestore; the caller logs the failure
+	if r.client == nil {
+		return errors.New("firestore client not initialized")
+	}
+
+	ref := r.client.Collection("auditLog").NewDoc()
+	entry.EntryID = ref.ID
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now()
+	}
+	_, err := ref.Create(ctx, entry)
+	return err
+}
+
+// List returns audit entries newest first, optionally filtered by actor and/or target
+func (r *AuditRepository) List(ctx context.Context, actorID, targetUserID, cursor string, limit int) ([]*models.AuditEntry, string, error) {
+	ctx, op := observe(ctx, "audit.List")
+	defer op.End()
+
+	query := r.client.Collection("auditLog").Query
+	if actorID != "" {
+		query = query.Where("actorId", "==", actorID)
+	}
+	if targetUserID != "" {
+		query = query.Where("targetUserId", "==", targetUserID)
+	}
+	return r.page(ctx, op, query, cursor, limit)
+}
+
+// ListVisibleTo returns the entries shown in a user's activity, newest first
+func (r *AuditRepository) ListVisibleTo(ctx context.Context, userID, cursor string, limit int) ([]*models.AuditEntry, string, error) {
+	ctx, op := observe(ctx, "audit.ListVisibleTo")
+	defer op.End()
+
+	query := r.client.Collection("auditLog").Where("visibleTo", "array-contains", userID)
+	return r.page(ctx, op, query, cursor, limit)
+}
+
+// DeleteOlderThan prunes entries created before the cutoff
+func (r *AuditRepository) DeleteOlderThan(ctx context.Context, cutoff time.Time) (int, error) {
+	ctx, op := observe(ctx, "audit.DeleteOlderThan")
+	defer op.End()
+
+	return deleteQuery(ctx, r.client, r.client.Collection("auditLog").Where("createdAt", "<", cutoff))
+}
+
+// AnonymizeUser strips a deleted user from the audit log while keeping the trail of what happened.
+// Their ID becomes models.DeletedUserID, their network details and old usernames are removed,
+// and the entries drop out of their activity view.
+func (r *AuditRepository) AnonymizeUser(ctx context.Context, userID string) (int, error) {
+	ctx, op := observe(ctx, "audit.AnonymizeUser")
+	defer op.End()
+
+	entries := r.client.Collection("auditLog")
+	total := 0
+
+	updated, err := updateQuery(ctx, r.client, entries.Where("actorId", "==", userID), func(doc *firestore.DocumentSnapshot) []firestore.Update {
+		updates := []firestore.Update{
+			{Path: "actorId", Value: models.DeletedUserID},
+			{Path: "ip", Value: firestore.Delete},
+			{Path: "userAgent", Value: firestore.Delete},
+		}
+		if action, _ := doc.DataAt("action"); action == models.AuditUsernameChange {
+			updates = append(updates,
+				firestore.Update{Path: "before", Value: firestore.Delete},
+				firestore.Update{Path: "after", Value: firestore.Delete})
+		}
+		return updates
+	})
+	total += updated
+	if err != nil {
+		return total, err
+	}
+
+	updated, err = updateQuery(ctx, r.client, entries.Where("targetUserId", "==", userID), func(doc *firestore.DocumentSnapshot) []firestore.Update {
+		return []firestore.Update{{Path: "targetUserId", Value: models.DeletedUserID}}
+	})
+	total += updated
+	if err != nil {
+		return total, err
+	}
+
+	updated, err = updateQuery(ctx, r.client, entries.Where("visibleTo", "array-contains", userID), func(doc *firestore.DocumentSnapshot) []firestore.Update {
+		return []firestore.Update{{Path: "visibleTo", Value: firestore.ArrayRemove(userID)}}
+	})
+	total += updated
+	return total, err
+}
+
+// page orders a query newest first and returns the entries after the one the cursor points to
+func (r *AuditRepository) page(ctx context.Context, op *call, query firestore.Query, cursor string, limit int) ([]*models.AuditEntry, string, error) {
+	query = query.OrderBy("createdAt", firestore.Desc).OrderBy(firestore.DocumentID, firestore.Desc)
+
+	if cursor != "" {
+		lastID, err := decodeCursor(cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		lastDoc, err := r.client.Collection("auditLog").Doc(lastID).Get(ctx)
+		if err != nil {
+			return nil, "", errors.New("invalid cursor")
+		}
+		query = query.StartAfter(lastDoc)
+	}
+
+	// Fetch one extra entry to know whether another page exists
+	iter := query.Limit(limit + 1).Documents(ctx)
+
+	entries := []*models.AuditEntry{}
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, "", err
+		}
+
+		var entry models.AuditEntry
+		if err := doc.DataTo(&entry); err != nil {
+			continue
+		}
+		entries = append(entries, &entry)
+	}
+
+	entries, nextCursor := trimPage(entries, limit, func(e *models.AuditEntry) string { return e.EntryID })
+
+	op.SetResultCount(len(entries))
+	return entries, nextCursor, nil
+}
@@ -22,8 +22,24 @@ func NewCooldownRepository() *CooldownRepository {
 
 // CreateCooldown creates a new cooldown with specified duration in minutes
 func (r *CooldownRepository) CreateCooldown(ctx context.Context, userID, targetUserID string, cooldownMinutes int) error {
+	ctx, op := observe(ctx, "cooldowns.CreateCooldown")
+	defer op.End()
+
+	return r.createIn(ctx, "cooldowns", userID, targetUserID, time.Duration(cooldownMinutes)*time.Minute)
+}
+
+// CreateResponseCooldown creates a cooldown for replying to triggers, kept separate from trigger cooldowns
+func (r *CooldownRepository) CreateResponseCooldown(ctx context.Context, userID, targetUserID string, duration time.Duration) error {
+	ctx, op := observe(ctx, "cooldowns.CreateResponseCooldown")
+	defer op.End()
+
+	return r.createIn(ctx, "responseCooldowns", userID, targetUserID, duration)
+}
+
+// createIn creates a cooldown document in the given collection
+func (r *CooldownRepository) createIn(ctx context.Context, collection, userID, targetUserID string, duration time.Duration) error {
 	now := time.Now()
-	expiresAt := now.Add(time.Duration(cooldownMinutes) * time.Minute)
+	expiresAt := now.Add(duration)
 
 	cooldown := models.Cooldown{
 		UserID:       userID,
@@ -32,7 +48,7 @@ func (r *CooldownRepository) CreateCooldown(ctx context.Context, userID, targetU
 		ExpiresAt:    expiresAt,
 	}
 
-	docRef, _, err := r.client.Collection("cooldowns").Add(ctx, cooldown)
+	docRef, _, err := r.client.Collection(collection).Add(ctx, cooldown)
 	if err != nil {
 		return err
 	}
@@ -46,9 +62,25 @@ func (r *CooldownRepository) CreateCooldown(ctx context.Context, userID, targetU
 
 // CheckActiveCooldown checks if there's an active cooldown between user and target
 func (r *CooldownRepository) CheckActiveCooldown(ctx context.Context, userID, targetUserID string) (*models.Cooldown, error) {
+	ctx, op := observe(ctx, "cooldowns.CheckActiveCooldown")
+	defer op.End()
+
+	return r.checkActiveIn(ctx, "cooldowns", userID, targetUserID)
+}
+
+// CheckActiveResponseCooldown checks if there's an active reply cooldown between user and target
+func (r *CooldownRepository) CheckActiveResponseCooldown(ctx context.Context, userID, targetUserID string) (*models.Cooldown, error) {
+	ctx, op := observe(ctx, "cooldowns.CheckActiveResponseCooldown")
+	defer op.End()
+
+	return r.checkActiveIn(ctx, "responseCooldowns", userID, targetUserID)
+}
+
+// checkActiveIn finds the latest unexpired cooldown in the given collection
+func (r *CooldownRepository) checkActiveIn(ctx context.Context, collection, userID, targetUserID string) (*models.Cooldown, error) {
 	now := time.Now()
 
-	iter := r.client.Collection("cooldowns").
+	iter := r.client.Collection(collection).
 		Where("userId", "==", userID).
 		Where("targetUserId", "==", targetUserID).
 		Where("expiresAt", ">", now).
@@ -74,6 +106,9 @@ func (r *CooldownRepository) CheckActiveCooldown(ctx context.Context, userID, ta
 
 // CleanupExpiredCooldowns removes expired cooldowns (optional cleanup)
 func (r *CooldownRepository) CleanupExpiredCooldowns(ctx context.Context) error {
+	ctx, op := observe(ctx, "cooldowns.CleanupExpiredCooldowns")
+	defer op.End()
+
 	now := time.Now()
 
 	iter := r.client.Collection("cooldowns").
@@ -116,6 +151,9 @@ func (r *CooldownRepository) CleanupExpiredCooldowns(ctx context.Context) error
 // UpdateActiveCooldown updates an active cooldown's expiry time based on new cooldown duration
 // Returns true if an active cooldown was updated, false if none exists
 func (r *CooldownRepository) UpdateActiveCooldown(ctx context.Context, userID, targetUserID string, newCooldownMinutes int) (bool, error) {
+	ctx, op := observe(ctx, "cooldowns.UpdateActiveCooldown")
+	defer op.End()
+
 	// Find active cooldown
 	cooldown, err := r.CheckActiveCooldown(ctx, userID, targetUserID)
 	if err != nil {
@@ -151,3 +189,21 @@ func (r *CooldownRepository) UpdateActiveCooldown(ctx context.Context, userID, t
 
 	return true, nil
 }
+
+// DeleteCooldownsForUser deletes trigger and response cooldowns in both directions for a user
+func (r *CooldownRepository) DeleteCooldownsForUser(ctx context.Context, userID string) (int, error) {
+	ctx, op := observe(ctx, "cooldowns.DeleteCooldownsForUser")
+	defer op.End()
+
+	total := 0
+	for _, collection := range []string{"cooldowns", "responseCooldowns"} {
+		for _, field := range []string{"userId", "targetUserId"} {
+			deleted, err := deleteQuery(ctx, r.client, r.client.Collection(collection).Where(field, "==", userID))
+			total += deleted
+			if err != nil {
+				return total, err
+			}
+		}
+	}
+	return total, nil
+}
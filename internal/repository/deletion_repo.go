@@ -0,0 +1,279 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/yourusername/rbd-service/internal/config"
+	"github.com/yourusername/rbd-service/internal/models"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type DeletionRepository struct {
+	client *firestore.Client
+}
+
+func NewDeletionRepository() *DeletionRepository {
+	return &DeletionRepository{
+		client: config.FirestoreClient,
+	}
+}
+
+func (r *DeletionRepository) jobRef(userID string) *firestore.DocumentRef {
+	return r.client.Collection("deletionJobs").Doc(userID)
+}
+
+// ScheduleDeletion creates (or restarts) the deletion job for a user and marks the user as pending deletion.
+// An already scheduled or running job is returned unchanged.
+func (r *DeletionRepository) ScheduleDeletion(ctx context.Context, userID string, scheduledFor time.Time) (*models.DeletionJob, error) {
+	ctx, op := observe(ctx, "deletions.ScheduleDeletion")
+	defer op.End()
+
+	ref := r.jobRef(userID)
+	userRef := r.client.Collection("users").Doc(userID)
+
+	var job models.DeletionJob
+	err := r.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		doc, err := tx.Get(ref)
+		if err != nil && status.Code(err) != codes.NotFound {
+			return err
+		}
+		if err == nil {
+			if err := doc.DataTo(&job); err != nil {
+				return err
+			}
+			if job.Status == models.DeletionScheduled || job.Status == models.DeletionRunning {
+				return nil
+			}
+		}
+
+		now := time.Now()
+		job = models.DeletionJob{
+			UserID:         userID,
+			Status:         models.DeletionScheduled,
+			RequestedAt:    now,
+			ScheduledFor:   scheduledFor,
+			CompletedSteps: []string{},
+			Deleted:        map[string]int{},
+			UpdatedAt:      now,
+		}
+		if err := tx.Set(ref, job); err != nil {
+			return err
+		}
+		return tx.Update(userRef, []firestore.Update{
+			{Path: "deletionScheduledFor", Value: scheduledFor},
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &job, nil
+}
+
+// CancelDeletion cancels a job that is still in its grace period.
+// Returns "deletion already in progress" once a worker has started on it.
+func (r *DeletionRepository) CancelDeletion(ctx context.Context, userID string) error {
+	ctx, op := observe(ctx, "deletions.CancelDeletion")
+	defer op.End()
+
+	ref := r.jobRef(userID)
+	userRef := r.client.Collection("users").Doc(userID)
+
+	return r.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		doc, err := tx.Get(ref)
+		if err != nil && status.Code(err) != codes.NotFound {
+			return err
+		}
+
+		if err == nil {
+			var job models.DeletionJob
+			if err := doc.DataTo(&job); err != nil {
+				return err
+			}
+			switch job.Status {
+			case models.DeletionRunning, models.DeletionFailed, models.DeletionParked, models.DeletionCompleted:
+				return errors.New("deletion already in progress")
+			case models.DeletionScheduled:
+				if err := tx.Update(ref, []firestore.Update{
+					{Path: "status", Value: models.DeletionCancelled},
+					{Path: "updatedAt", Value: time.Now()},
+				}); err != nil {
+					return err
+				}
+			}
+		}
+
+		return tx.Update(userRef, []firestore.Update{
+			{Path: "deletionScheduledFor", Value: firestore.Delete},
+		})
+	})
+}
+
+// ClaimJob takes a lease on a job that is due (or whose previous worker died or failed).
+// Returns nil if the job isn't claimable.
+func (r *DeletionRepository) ClaimJob(ctx context.Context, userID string, lease time.Duration) (*models.DeletionJob, error) {
+	ctx, op := observe(ctx, "deletions.ClaimJob")
+	defer op.End()
+
+	ref := r.jobRef(userID)
+
+	var claimed *models.DeletionJob
+	err := r.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		claimed = nil
+
+		doc, err := tx.Get(ref)
+		if err != nil {
+			return err
+		}
+		var job models.DeletionJob
+		if err := doc.DataTo(&job); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		switch job.Status {
+		case models.DeletionScheduled:
+			if now.Before(job.ScheduledFor) {
+				return nil
+			}
+		case models.DeletionRunning:
+			if job.LeaseUntil != nil && now.Before(*job.LeaseUntil) {
+				return nil
+			}
+		case models.DeletionFailed:
+			if job.RetryAt != nil && now.Before(*job.RetryAt) {
+				return nil
+			}
+		default:
+			return nil
+		}
+
+		leaseUntil := now.Add(lease)
+		job.Status = models.DeletionRunning
+		job.LeaseUntil = &leaseUntil
+		job.Attempts++
+		job.UpdatedAt = now
+		if err := tx.Set(ref, job); err != nil {
+			return err
+		}
+		claimed = &job
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return claimed, nil
+}
+
+// GetClaimableJobIDs lists users whose deletion job is due, failed and ready for a retry, or running under an expired lease
+func (r *DeletionRepository) GetClaimableJobIDs(ctx context.Context, limit int) ([]string, error) {
+	ctx, op := observe(ctx, "deletions.GetClaimableJobIDs")
+	defer op.End()
+
+	now := time.Now()
+	queries := []firestore.Query{
+		r.client.Collection("deletionJobs").
+			Where("status", "==", string(models.DeletionScheduled)).
+			Where("scheduledFor", "<=", now),
+		r.client.Collection("deletionJobs").
+			Where("status", "==", string(models.DeletionRunning)).
+			Where("leaseUntil", "<", now),
+		r.client.Collection("deletionJobs").
+			Where("status", "==", string(models.DeletionFailed)).
+			Where("retryAt", "<=", now),
+	}
+
+	var ids []string
+	for _, q := range queries {
+		iter := q.Limit(limit).Documents(ctx)
+		for {
+			doc, err := iter.Next()
+			if err == iterator.Done {
+				break
+			}
+			if err != nil {
+				return nil, err
+			}
+			ids = append(ids, doc.Ref.ID)
+		}
+	}
+
+	op.SetResultCount(len(ids))
+	return ids, nil
+}
+
+// RecordStep marks a step as completed and extends the lease
+func (r *DeletionRepository) RecordStep(ctx context.Context, userID, step string, deleted int, lease time.Duration) error {
+	ctx, op := observe(ctx, "deletions.RecordStep")
+	defer op.End()
+
+	now := time.Now()
+	_, err := r.jobRef(userID).Update(ctx, []firestore.Update{
+		{Path: "completedSteps", Value: firestore.ArrayUnion(step)},
+		{FieldPath: firestore.FieldPath{"deleted", step}, Value: deleted},
+		{Path: "leaseUntil", Value: now.Add(lease)},
+		{Path: "updatedAt", Value: now},
+	})
+	return err
+}
+
+// CompleteJob marks a job as finished
+func (r *DeletionRepository) CompleteJob(ctx context.Context, userID string) error {
+	ctx, op := observe(ctx, "deletions.CompleteJob")
+	defer op.End()
+
+	now := time.Now()
+	_, err := r.jobRef(userID).Update(ctx, []firestore.Update{
+		{Path: "status", Value: models.DeletionCompleted},
+		{Path: "completedAt", Value: now},
+		{Path: "leaseUntil", Value: firestore.Delete},
+		{Path: "lastError", Value: firestore.Delete},
+		{Path: "retryAt", Value: firestore.Delete},
+		{Path: "updatedAt", Value: now},
+	})
+	return err
+}
+
+// FailJob marks a job as failed so a worker pass after retryAt retries it
+func (r *DeletionRepository) FailJob(ctx context.Context, userID string, cause error, retryAt time.Time) error {
+	ctx, op := observe(ctx, "deletions.FailJob")
+	defer op.End()
+
+	_, err := r.jobRef(userID).Update(ctx, []firestore.Update{
+		{Path: "status", Value: models.DeletionFailed},
+		{Path: "lastError", Value: cause.Error()},
+		{Path: "retryAt", Value: retryAt},
+		{Path: "leaseUntil", Value: firestore.Delete},
+		{Path: "updatedAt", Value: time.Now()},
+	})
+	return err
+}
+
+// ParkJob marks a job that keeps failing as parked; workers leave it alone until an operator steps in
+func (r *DeletionRepository) ParkJob(ctx context.Context, userID string, cause error) error {
+	ctx, op := observe(ctx, "deletions.ParkJob")
+	defer op.End()
+
+	_, err := r.jobRef(userID).Update(ctx, []firestore.Update{
+		{Path: "status", Value: models.DeletionParked},
+		{Path: "lastError", Value: cause.Error()},
+		{Path: "retryAt", Value: firestore.Delete},
+		{Path: "leaseUntil", Value: firestore.Delete},
+		{Path: "updatedAt", Value: time.Now()},
+	})
+	return err
+}
+
+// CountParked counts jobs that were given up on
+func (r *DeletionRepository) CountParked(ctx context.Context) (int, error) {
+	ctx, op := observe(ctx, "deletions.CountParked")
+	defer op.End()
+
+	return countQuery(ctx, r.client.Collection("deletionJobs").Where("status", "==", string(models.DeletionParked)))
+}
@@ -0,0 +1,188 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/yourusername/rbd-service/internal/config"
+	"github.com/yourusername/rbd-service/internal/models"
+	"google.golang.org/api/iterator"
+)
+
+type ExportRepository struct {
+	client *firestore.Client
+}
+
+func NewExportRepository() *ExportRepository {
+	return &ExportRepository{
+		client: config.FirestoreClient,
+	}
+}
+
+// CreateExport creates a pending export for a user
+func (r *ExportRepository) CreateExport(ctx context.Context, userID string) (*models.DataExport, error) {
+	ctx, op := observe(ctx, "exports.CreateExport")
+	defer op.End()
+
+	ref := r.client.Collection("exports").NewDoc()
+	export := &models.DataExport{
+		ExportID:      ref.ID,
+		UserID:        userID,
+		Status:        models.ExportPending,
+		FormatVersion: models.ExportFormatVersion,
+		RequestedAt:   time.Now(),
+	}
+	if _, err := ref.Create(ctx, export); err != nil {
+		return nil, err
+	}
+	return export, nil
+}
+
+// GetExport retrieves an export by ID
+func (r *ExportRepository) GetExport(ctx context.Context, exportID string) (*models.DataExport, error) {
+	ctx, op := observe(ctx, "exports.GetExport")
+	defer op.End()
+
+	doc, err := r.client.Collection("exports").Doc(exportID).Get(ctx)
+	if err != nil {
+		return nil, errors.New("export not found")
+	}
+
+	var export models.DataExport
+	if err := doc.DataTo(&export); err != nil {
+		return nil, err
+	}
+	return &export, nil
+}
+
+// GetExportsForUser lists a user's exports, newest first
+func (r *ExportRepository) GetExportsForUser(ctx context.Context, userID string) ([]*models.DataExport, error) {
+	ctx, op := observe(ctx, "exports.GetExportsForUser")
+	defer op.End()
+
+	iter := r.client.Collection("exports").
+		Where("userId", "==", userID).
+		Documents(ctx)
+
+	var exports []*models.DataExport
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var export models.DataExport
+		if err := doc.DataTo(&export); err != nil {
+			continue
+		}
+		exports = append(exports, &export)
+	}
+
+	// Sorted here rather than in the query so no composite index is needed
+	sort.Slice(exports, func(i, j int) bool {
+		return exports[i].RequestedAt.After(exports[j].RequestedAt)
+	})
+	op.SetResultCount(len(exports))
+	return exports, nil
+}
+
+// MarkReady records the finished archive
+func (r *ExportRepository) MarkReady(ctx context.Context, exportID, blobKey string, sizeBytes int, expiresAt time.Time) error {
+	ctx, op := observe(ctx, "exports.MarkReady")
+	defer op.End()
+
+	_, err := r.client.Collection("exports").Doc(exportID).Update(ctx, []firestore.Update{
+		{Path: "status", Value: models.ExportReady},
+		{Path: "blobKey", Value: blobKey},
+		{Path: "sizeBytes", Value: sizeBytes},
+		{Path: "completedAt", Value: time.Now()},
+		{Path: "expiresAt", Value: expiresAt},
+	})
+	return err
+}
+
+// MarkFailed records why an export couldn't be built
+func (r *ExportRepository) MarkFailed(ctx context.Context, exportID string, cause error) error {
+	ctx, op := observe(ctx, "exports.MarkFailed")
+	defer op.End()
+
+	_, err := r.client.Collection("exports").Doc(exportID).Update(ctx, []firestore.Update{
+		{Path: "status", Value: models.ExportFailed},
+		{Path: "error", Value: cause.Error()},
+		{Path: "completedAt", Value: time.Now()},
+	})
+	return err
+}
+
+// MarkExpired records that the archive has been deleted
+func (r *ExportRepository) MarkExpired(ctx context.Context, exportID string) error {
+	ctx, op := observe(ctx, "exports.MarkExpired")
+	defer op.End()
+
+	_, err := r.client.Collection("exports").Doc(exportID).Update(ctx, []firestore.Update{
+		{Path: "status", Value: models.ExportExpired},
+		{Path: "blobKey", Value: firestore.Delete},
+		{Path: "downloadTokenHash", Value: firestore.Delete},
+		{Path: "downloadExpiresAt", Value: firestore.Delete},
+	})
+	return err
+}
+
+// ListExpired returns up to limit ready exports whose archive expired before the given time
+func (r *ExportRepository) ListExpired(ctx context.Context, before time.Time, limit int) ([]*models.DataExport, error) {
+	ctx, op := observe(ctx, "exports.ListExpired")
+	defer op.End()
+
+	iter := r.client.Collection("exports").
+		Where("status", "==", string(models.ExportReady)).
+		Where("expiresAt", "<=", before).
+		OrderBy("expiresAt", firestore.Asc).
+		Limit(limit).
+		Documents(ctx)
+
+	var exports []*models.DataExport
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var export models.DataExport
+		if err := doc.DataTo(&export); err != nil {
+			continue
+		}
+		exports = append(exports, &export)
+	}
+
+	op.SetResultCount(len(exports))
+	return exports, nil
+}
+
+// SetDownloadToken replaces the export's download link token (only the hash is stored)
+func (r *ExportRepository) SetDownloadToken(ctx context.Context, exportID, tokenHash string, expiresAt time.Time) error {
+	ctx, op := observe(ctx, "exports.SetDownloadToken")
+	defer op.End()
+
+	_, err := r.client.Collection("exports").Doc(exportID).Update(ctx, []firestore.Update{
+		{Path: "downloadTokenHash", Value: tokenHash},
+		{Path: "downloadExpiresAt", Value: expiresAt},
+	})
+	return err
+}
+
+// DeleteExportsForUser deletes every export record of a user (archives must be deleted separately)
+func (r *ExportRepository) DeleteExportsForUser(ctx context.Context, userID string) (int, error) {
+	ctx, op := observe(ctx, "exports.DeleteExportsForUser")
+	defer op.End()
+
+	return deleteQuery(ctx, r.client, r.client.Collection("exports").Where("userId", "==", userID))
+}
@@ -22,6 +22,9 @@ func NewFriendRepository() *FriendRepository {
 
 // CreateFriendRequest creates a new friend request
 func (r *FriendRepository) CreateFriendRequest(ctx context.Context, user1ID, user2ID string) (string, error) {
+	ctx, op := observe(ctx, "friends.CreateFriendRequest")
+	defer op.End()
+
 	friendship := models.Friendship{
 		User1ID:              user1ID,
 		User2ID:              user2ID,
@@ -52,6 +55,9 @@ func (r *FriendRepository) CreateFriendRequest(ctx context.Context, user1ID, use
 
 // GetFriendship retrieves a friendship by ID
 func (r *FriendRepository) GetFriendship(ctx context.Context, friendshipID string) (*models.Friendship, error) {
+	ctx, op := observe(ctx, "friends.GetFriendship")
+	defer op.End()
+
 	doc, err := r.client.Collection("friends").Doc(friendshipID).Get(ctx)
 	if err != nil {
 		return nil, err
@@ -67,6 +73,9 @@ func (r *FriendRepository) GetFriendship(ctx context.Context, friendshipID strin
 
 // GetAcceptedFriends retrieves all accepted friends for a user
 func (r *FriendRepository) GetAcceptedFriends(ctx context.Context, userID string) ([]*models.Friendship, error) {
+	ctx, op := observe(ctx, "friends.GetAcceptedFriends")
+	defer op.End()
+
 	var friendships []*models.Friendship
 
 	// Query where user is user1
@@ -113,11 +122,15 @@ func (r *FriendRepository) GetAcceptedFriends(ctx context.Context, userID string
 		friendships = append(friendships, &friendship)
 	}
 
+	op.SetResultCount(len(friendships))
 	return friendships, nil
 }
 
 // GetPendingRequests retrieves pending friend requests for a user (where they are user2)
 func (r *FriendRepository) GetPendingRequests(ctx context.Context, userID string) ([]*models.Friendship, error) {
+	ctx, op := observe(ctx, "friends.GetPendingRequests")
+	defer op.End()
+
 	var friendships []*models.Friendship
 
 	iter := r.client.Collection("friends").
@@ -141,11 +154,15 @@ func (r *FriendRepository) GetPendingRequests(ctx context.Context, userID string
 		friendships = append(friendships, &friendship)
 	}
 
+	op.SetResultCount(len(friendships))
 	return friendships, nil
 }
 
 // AcceptFriendRequest accepts a friend request
 func (r *FriendRepository) AcceptFriendRequest(ctx context.Context, friendshipID string) error {
+	ctx, op := observe(ctx, "friends.AcceptFriendRequest")
+	defer op.End()
+
 	now := time.Now()
 	_, err := r.client.Collection("friends").Doc(friendshipID).Update(ctx, []firestore.Update{
 		{Path: "status", Value: string(models.StatusAccepted)},
@@ -156,6 +173,9 @@ func (r *FriendRepository) AcceptFriendRequest(ctx context.Context, friendshipID
 
 // RejectFriendRequest rejects a friend request
 func (r *FriendRepository) RejectFriendRequest(ctx context.Context, friendshipID string) error {
+	ctx, op := observe(ctx, "friends.RejectFriendRequest")
+	defer op.End()
+
 	_, err := r.client.Collection("friends").Doc(friendshipID).Update(ctx, []firestore.Update{
 		{Path: "status", Value: string(models.StatusRejected)},
 	})
@@ -164,12 +184,18 @@ func (r *FriendRepository) RejectFriendRequest(ctx context.Context, friendshipID
 
 // DeleteFriendship deletes a friendship
 func (r *FriendRepository) DeleteFriendship(ctx context.Context, friendshipID string) error {
+	ctx, op := observe(ctx, "friends.DeleteFriendship")
+	defer op.End()
+
 	_, err := r.client.Collection("friends").Doc(friendshipID).Delete(ctx)
 	return err
 }
 
 // UpdateMuteStatus updates the mute status for a friendship
 func (r *FriendRepository) UpdateMuteStatus(ctx context.Context, friendshipID string, isUser1 bool, muted bool) error {
+	ctx, op := observe(ctx, "friends.UpdateMuteStatus")
+	defer op.End()
+
 	fieldName := "user2Muted"
 	if isUser1 {
 		fieldName = "user1Muted"
@@ -182,6 +208,9 @@ func (r *FriendRepository) UpdateMuteStatus(ctx context.Context, friendshipID st
 
 // UpdateCooldown updates the cooldown minutes for a friendship
 func (r *FriendRepository) UpdateCooldown(ctx context.Context, friendshipID string, isUser1 bool, cooldownMinutes int) error {
+	ctx, op := observe(ctx, "friends.UpdateCooldown")
+	defer op.End()
+
 	// User1CooldownMinutes = cooldown User1 sets = how often User2 can trigger User1
 	// User2CooldownMinutes = cooldown User2 sets = how often User1 can trigger User2
 	// When isUser1=true, User1 is setting their cooldown, so update user1CooldownMinutes
@@ -197,6 +226,9 @@ func (r *FriendRepository) UpdateCooldown(ctx context.Context, friendshipID stri
 
 // CheckExistingFriendship checks if a friendship already exists between two users
 func (r *FriendRepository) CheckExistingFriendship(ctx context.Context, user1ID, user2ID string) (*models.Friendship, error) {
+	ctx, op := observe(ctx, "friends.CheckExistingFriendship")
+	defer op.End()
+
 	// Check both directions
 	iter := r.client.Collection("friends").
 		Where("user1Id", "==", user1ID).
@@ -229,3 +261,75 @@ func (r *FriendRepository) CheckExistingFriendship(ctx context.Context, user1ID,
 
 	return nil, nil // No existing friendship
 }
+
+// GetAllFriendships retrieves every friendship or request involving a user, keyed by the other user's ID
+func (r *FriendRepository) GetAllFriendships(ctx context.Context, userID string) (map[string]*models.Friendship, error) {
+	ctx, op := observe(ctx, "friends.GetAllFriendships")
+	defer op.End()
+
+	friendships := map[string]*models.Friendship{}
+
+	for _, field := range []string{"user1Id", "user2Id"} {
+		iter := r.client.Collection("friends").
+			Where(field, "==", userID).
+			Documents(ctx)
+
+		for {
+			doc, err := iter.Next()
+			if err == iterator.Done {
+				break
+			}
+			if err != nil {
+				return nil, err
+			}
+
+			var friendship models.Friendship
+			if err := doc.DataTo(&friendship); err != nil {
+				continue
+			}
+
+			otherID := friendship.User2ID
+			if friendship.User2ID == userID {
+				otherID = friendship.User1ID
+			}
+			friendships[otherID] = &friendship
+		}
+	}
+
+	op.SetResultCount(len(friendships))
+	return friendships, nil
+}
+
+// CountAcceptedFriends counts a user's accepted friendships
+func (r *FriendRepository) CountAcceptedFriends(ctx context.Context, userID string) (int, error) {
+	ctx, op := observe(ctx, "friends.CountAcceptedFriends")
+	defer op.End()
+
+	total := 0
+	for _, field := range []string{"user1Id", "user2Id"} {
+		count, err := countQuery(ctx, r.client.Collection("friends").
+			Where(field, "==", userID).
+			Where("status", "==", string(models.StatusAccepted)))
+		if err != nil {
+			return 0, err
+		}
+		total += count
+	}
+	return total, nil
+}
+
+// DeleteFriendshipsForUser deletes every friendship and request involving a user
+func (r *FriendRepository) DeleteFriendshipsForUser(ctx context.Context, userID string) (int, error) {
+	ctx, op := observe(ctx, "friends.DeleteFriendshipsForUser")
+	defer op.End()
+
+	total := 0
+	for _, field := range []string{"user1Id", "user2Id"} {
+		deleted, err := deleteQuery(ctx, r.client, r.client.Collection("friends").Where(field, "==", userID))
+		total += deleted
+		if err != nil {
+			return total, err
+		}
+	}
+	return total, nil
+}
@@ -2,6 +2,8 @@ package repository
 
 import (
 	"context"
+	"errors"
+	"sort"
 	"time"
 
 	"cloud.google.com/go/firestore"
@@ -20,107 +22,332 @@ func NewHistoryRepository() *HistoryRepository {
 	}
 }
 
-// CreateHistory creates a new history record
-func (r *HistoryRepository) CreateHistory(ctx context.Context, senderID, receiverID, senderUsername string) error {
+// CreateHistory creates a new history record and returns its ID
+func (r *HistoryRepository) CreateHistory(ctx context.Context, senderID, receiverID, senderUsername string) (string, error) {
+	ctx, op := observe(ctx, "history.CreateHistory")
+	defer op.End()
+
+	history := models.History{
+		PairKey:        models.PairKey(senderID, receiverID),
+		VisibleTo:      []string{senderID, receiverID},
+		SenderID:       senderID,
+		ReceiverID:     receiverID,
+		SenderUsername: senderUsername,
+		TriggeredAt:    time.Now(),
+		Type:           models.HistoryTypeTrigger,
+		State:          models.TriggerStateSent,
+	}
+
+	return r.addHistory(ctx, history)
+}
+
+// CreateResponse creates a history record for a reply to an earlier trigger and returns its ID
+func (r *HistoryRepository) CreateResponse(ctx context.Context, senderID, receiverID, senderUsername, responseTo string, response models.ResponseKind) (string, error) {
+	ctx, op := observe(ctx, "history.CreateResponse")
+	defer op.End()
+
 	history := models.History{
+		PairKey:        models.PairKey(senderID, receiverID),
+		VisibleTo:      []string{senderID, receiverID},
 		SenderID:       senderID,
 		ReceiverID:     receiverID,
 		SenderUsername: senderUsername,
 		TriggeredAt:    time.Now(),
+		Type:           models.HistoryTypeResponse,
+		State:          models.TriggerStateSent,
+		ResponseTo:     responseTo,
+		Response:       response,
 	}
 
+	return r.addHistory(ctx, history)
+}
+
+// addHistory stores a history record and backfills its generated ID
+func (r *HistoryRepository) addHistory(ctx context.Context, history models.History) (string, error) {
 	docRef, _, err := r.client.Collection("history").Add(ctx, history)
 	if err != nil {
-		return err
+		return "", err
 	}
 
 	// Update with the generated ID
 	_, err = docRef.Update(ctx, []firestore.Update{
 		{Path: "historyId", Value: docRef.ID},
 	})
-	return err
+	if err != nil {
+		return "", err
+	}
+
+	return docRef.ID, nil
 }
 
-// GetHistoryBetweenUsers retrieves history between two users
-func (r *HistoryRepository) GetHistoryBetweenUsers(ctx context.Context, user1ID, user2ID string, page, limit int) ([]*models.History, int, error) {
-	offset := (page - 1) * limit
+// GetHistoryByID retrieves a single history record
+func (r *HistoryRepository) GetHistoryByID(ctx context.Context, historyID string) (*models.History, error) {
+	ctx, op := observe(ctx, "history.GetHistoryByID")
+	defer op.End()
 
-	// Get all records where either user is sender or receiver
-	var allHistory []*models.History
+	doc, err := r.client.Collection("history").Doc(historyID).Get(ctx)
+	if err != nil {
+		return nil, err
+	}
 
-	// Query 1: user1 sent to user2
-	iter1 := r.client.Collection("history").
-		Where("senderId", "==", user1ID).
-		Where("receiverId", "==", user2ID).
+	var history models.History
+	if err := doc.DataTo(&history); err != nil {
+		return nil, err
+	}
+	normalizeHistory(&history)
+
+	return &history, nil
+}
+
+// UpdateTriggerState moves a trigger forward in its lifecycle.
+// States never move backwards; skipped earlier states get the same timestamp.
+// Returns the updated record and whether the state actually advanced.
+func (r *HistoryRepository) UpdateTriggerState(ctx context.Context, historyID string, state models.TriggerState) (*models.History, bool, error) {
+	ctx, op := observe(ctx, "history.UpdateTriggerState")
+	defer op.End()
+
+	docRef := r.client.Collection("history").Doc(historyID)
+
+	var history models.History
+	advanced := false
+	err := r.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		advanced = false
+
+		doc, err := tx.Get(docRef)
+		if err != nil {
+			return err
+		}
+		if err := doc.DataTo(&history); err != nil {
+			return err
+		}
+		normalizeHistory(&history)
+
+		if state.Rank() <= history.State.Rank() {
+			return nil // Already at or past this state
+		}
+
+		now := time.Now()
+		updates := []firestore.Update{
+			{Path: "state", Value: string(state)},
+		}
+		if history.DeliveredAt == nil {
+			history.DeliveredAt = &now
+			updates = append(updates, firestore.Update{Path: "deliveredAt", Value: now})
+		}
+		if state.Rank() >= models.TriggerStateOpened.Rank() && history.OpenedAt == nil {
+			history.OpenedAt = &now
+			updates = append(updates, firestore.Update{Path: "openedAt", Value: now})
+		}
+		if state.Rank() >= models.TriggerStateOpened.Rank() && !history.Read {
+			// Opening a trigger on the device also clears it from the inbox
+			history.Read = true
+			history.ReadAt = &now
+			updates = append(updates,
+				firestore.Update{Path: "read", Value: true},
+				firestore.Update{Path: "readAt", Value: now},
+			)
+		}
+		if state == models.TriggerStateResponded && history.RespondedAt == nil {
+			history.RespondedAt = &now
+			updates = append(updates, firestore.Update{Path: "respondedAt", Value: now})
+		}
+		history.State = state
+		advanced = true
+
+		return tx.Update(docRef, updates)
+	})
+	if err != nil {
+		return nil, false, err
+	}
+
+	return &history, advanced, nil
+}
+
+// GetHistoryBetweenUsers retrieves history between two users in both directions, newest first,
+// as seen by user1 (records user1 deleted from their side are skipped).
+// Returns the page and a cursor for the next page (empty when there are no more).
+func (r *HistoryRepository) GetHistoryBetweenUsers(ctx context.Context, user1ID, user2ID, cursor string, limit int) ([]*models.History, string, error) {
+	ctx, op := observe(ctx, "history.GetHistoryBetweenUsers")
+	defer op.End()
+
+	query := r.client.Collection("history").
+		Where("pairKey", "==", models.PairKey(user1ID, user2ID)).
+		Where("visibleTo", "array-contains", user1ID).
 		OrderBy("triggeredAt", firestore.Desc).
+		OrderBy(firestore.DocumentID, firestore.Desc)
+
+	return r.page(ctx, op, query, cursor, limit)
+}
+
+// CountHistoryBetweenUsers counts history between two users in both directions, as seen by user1
+func (r *HistoryRepository) CountHistoryBetweenUsers(ctx context.Context, user1ID, user2ID string) (int, error) {
+	ctx, op := observe(ctx, "history.CountHistoryBetweenUsers")
+	defer op.End()
+
+	query := r.client.Collection("history").
+		Where("pairKey", "==", models.PairKey(user1ID, user2ID)).
+		Where("visibleTo", "array-contains", user1ID)
+
+	return countQuery(ctx, query)
+}
+
+// HasSharedHistory reports whether any history exists between two users, regardless of who deleted what.
+// History is only ever written between accepted friends, so this also means they were friends once.
+func (r *HistoryRepository) HasSharedHistory(ctx context.Context, user1ID, user2ID string) (bool, error) {
+	ctx, op := observe(ctx, "history.HasSharedHistory")
+	defer op.End()
+
+	iter := r.client.Collection("history").
+		Where("pairKey", "==", models.PairKey(user1ID, user2ID)).
+		Limit(1).
 		Documents(ctx)
 
-	for {
-		doc, err := iter1.Next()
-		if err == iterator.Done {
-			break
-		}
+	_, err := iter.Next()
+	if err == iterator.Done {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// HideHistory removes history records from one user's side.
+// When historyID is empty every record with the friend is hidden.
+// Records nobody can see anymore are deleted. Returns the number of records affected.
+func (r *HistoryRepository) HideHistory(ctx context.Context, userID, friendUserID, historyID string) (int, error) {
+	ctx, op := observe(ctx, "history.HideHistory")
+	defer op.End()
+
+	var docs []*firestore.DocumentSnapshot
+	pairKey := models.PairKey(userID, friendUserID)
+
+	if historyID != "" {
+		doc, err := r.client.Collection("history").Doc(historyID).Get(ctx)
 		if err != nil {
-			return nil, 0, err
+			return 0, errors.New("history not found")
+		}
+		if key, _ := doc.DataAt("pairKey"); key != pairKey {
+			return 0, errors.New("history not found")
+		}
+		docs = append(docs, doc)
+	} else {
+		iter := r.client.Collection("history").
+			Where("pairKey", "==", pairKey).
+			Where("visibleTo", "array-contains", userID).
+			Documents(ctx)
+		for {
+			doc, err := iter.Next()
+			if err == iterator.Done {
+				break
+			}
+			if err != nil {
+				return 0, err
+			}
+			docs = append(docs, doc)
 		}
+	}
+
+	batch := r.client.Batch()
+	count := 0
+	affected := 0
+	now := time.Now()
 
+	for _, doc := range docs {
 		var history models.History
 		if err := doc.DataTo(&history); err != nil {
 			continue
 		}
-		allHistory = append(allHistory, &history)
+
+		visible, remaining := history.VisibilityFor(userID)
+		if !visible {
+			continue
+		}
+
+		if remaining == 0 {
+			batch.Delete(doc.Ref)
+		} else {
+			updates := []firestore.Update{
+				{Path: "visibleTo", Value: firestore.ArrayRemove(userID)},
+			}
+			// Hidden records shouldn't keep counting towards the receiver's badge
+			if history.ReceiverID == userID && !history.Read {
+				updates = append(updates,
+					firestore.Update{Path: "read", Value: true},
+					firestore.Update{Path: "readAt", Value: now},
+				)
+			}
+			batch.Update(doc.Ref, updates)
+		}
+		count++
+		affected++
+
+		// Firestore batch limit is 500
+		if count >= 500 {
+			if _, err := batch.Commit(ctx); err != nil {
+				return affected, err
+			}
+			batch = r.client.Batch()
+			count = 0
+		}
 	}
 
-	// Query 2: user2 sent to user1
-	iter2 := r.client.Collection("history").
-		Where("senderId", "==", user2ID).
-		Where("receiverId", "==", user1ID).
-		OrderBy("triggeredAt", firestore.Desc).
+	if count > 0 {
+		if _, err := batch.Commit(ctx); err != nil {
+			return affected, err
+		}
+	}
+
+	return affected, nil
+}
+
+// DeleteHistoryBetweenUsers permanently deletes all history between two users
+func (r *HistoryRepository) DeleteHistoryBetweenUsers(ctx context.Context, user1ID, user2ID string) error {
+	ctx, op := observe(ctx, "history.DeleteHistoryBetweenUsers")
+	defer op.End()
+
+	iter := r.client.Collection("history").
+		Where("pairKey", "==", models.PairKey(user1ID, user2ID)).
 		Documents(ctx)
 
+	batch := r.client.Batch()
+	count := 0
+
 	for {
-		doc, err := iter2.Next()
+		doc, err := iter.Next()
 		if err == iterator.Done {
 			break
 		}
 		if err != nil {
-			return nil, 0, err
+			return err
 		}
 
-		var history models.History
-		if err := doc.DataTo(&history); err != nil {
-			continue
-		}
-		allHistory = append(allHistory, &history)
-	}
+		batch.Delete(doc.Ref)
+		count++
 
-	// Sort by triggeredAt descending (most recent first)
-	// Simple bubble sort for small datasets
-	for i := 0; i < len(allHistory)-1; i++ {
-		for j := 0; j < len(allHistory)-i-1; j++ {
-			if allHistory[j].TriggeredAt.Before(allHistory[j+1].TriggeredAt) {
-				allHistory[j], allHistory[j+1] = allHistory[j+1], allHistory[j]
+		// Firestore batch limit is 500
+		if count >= 500 {
+			if _, err := batch.Commit(ctx); err != nil {
+				return err
 			}
+			batch = r.client.Batch()
+			count = 0
 		}
 	}
 
-	total := len(allHistory)
-
-	// Paginate
-	start := offset
-	end := offset + limit
-	if start >= total {
-		return []*models.History{}, total, nil
-	}
-	if end > total {
-		end = total
+	if count > 0 {
+		_, err := batch.Commit(ctx)
+		return err
 	}
 
-	return allHistory[start:end], total, nil
+	return nil
 }
 
 // GetLastTriggerTime gets the last time a user triggered another user
 func (r *HistoryRepository) GetLastTriggerTime(ctx context.Context, senderID, receiverID string) (*time.Time, error) {
+	ctx, op := observe(ctx, "history.GetLastTriggerTime")
+	defer op.End()
+
 	iter := r.client.Collection("history").
 		Where("senderId", "==", senderID).
 		Where("receiverId", "==", receiverID).
@@ -143,3 +370,207 @@ func (r *HistoryRepository) GetLastTriggerTime(ctx context.Context, senderID, re
 
 	return &history.TriggeredAt, nil
 }
+
+// GetInbox retrieves everything a user has received, newest first.
+// Returns the page and a cursor for the next page (empty when there are no more).
+func (r *HistoryRepository) GetInbox(ctx context.Context, userID, cursor string, limit int) ([]*models.History, string, error) {
+	ctx, op := observe(ctx, "history.GetInbox")
+	defer op.End()
+
+	query := r.client.Collection("history").
+		Where("receiverId", "==", userID).
+		Where("visibleTo", "array-contains", userID).
+		OrderBy("triggeredAt", firestore.Desc).
+		OrderBy(firestore.DocumentID, firestore.Desc)
+
+	return r.page(ctx, op, query, cursor, limit)
+}
+
+// page runs an ordered history query starting after the record the cursor points to
+func (r *HistoryRepository) page(ctx context.Context, op *call, query firestore.Query, cursor string, limit int) ([]*models.History, string, error) {
+	if cursor != "" {
+		lastID, err := decodeCursor(cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		lastDoc, err := r.client.Collection("history").Doc(lastID).Get(ctx)
+		if err != nil {
+			return nil, "", errors.New("invalid cursor")
+		}
+		query = query.StartAfter(lastDoc)
+	}
+
+	// Fetch one extra record to know whether another page exists
+	iter := query.Limit(limit + 1).Documents(ctx)
+
+	items := []*models.History{}
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, "", err
+		}
+
+		var history models.History
+		if err := doc.DataTo(&history); err != nil {
+			continue
+		}
+		normalizeHistory(&history)
+		items = append(items, &history)
+	}
+
+	items, nextCursor := trimPage(items, limit, func(h *models.History) string { return h.HistoryID })
+
+	op.SetResultCount(len(items))
+	return items, nextCursor, nil
+}
+
+// CountUnread counts received history records the user hasn't read or deleted from their side
+func (r *HistoryRepository) CountUnread(ctx context.Context, userID string) (int, error) {
+	ctx, op := observe(ctx, "history.CountUnread")
+	defer op.End()
+
+	query := r.client.Collection("history").
+		Where("receiverId", "==", userID).
+		Where("read", "==", false).
+		Where("visibleTo", "array-contains", userID)
+
+	return countQuery(ctx, query)
+}
+
+// MarkRead marks the given received records as read, or every unread record when none are given
+func (r *HistoryRepository) MarkRead(ctx context.Context, userID string, historyIDs []string) error {
+	ctx, op := observe(ctx, "history.MarkRead")
+	defer op.End()
+
+	now := time.Now()
+	updates := []firestore.Update{
+		{Path: "read", Value: true},
+		{Path: "readAt", Value: now},
+	}
+
+	var refs []*firestore.DocumentRef
+	if len(historyIDs) > 0 {
+		for _, id := range historyIDs {
+			refs = append(refs, r.client.Collection("history").Doc(id))
+		}
+		docs, err := r.client.GetAll(ctx, refs)
+		if err != nil {
+			return err
+		}
+		refs = refs[:0]
+		for _, doc := range docs {
+			if !doc.Exists() {
+				continue
+			}
+			// Only the receiver can mark a record as read
+			if receiverID, _ := doc.DataAt("receiverId"); receiverID != userID {
+				continue
+			}
+			refs = append(refs, doc.Ref)
+		}
+	} else {
+		iter := r.client.Collection("history").
+			Where("receiverId", "==", userID).
+			Where("read", "==", false).
+			Documents(ctx)
+		for {
+			doc, err := iter.Next()
+			if err == iterator.Done {
+				break
+			}
+			if err != nil {
+				return err
+			}
+			refs = append(refs, doc.Ref)
+		}
+	}
+
+	batch := r.client.Batch()
+	count := 0
+	for _, ref := range refs {
+		batch.Update(ref, updates)
+		count++
+
+		// Firestore batch limit is 500
+		if count >= 500 {
+			if _, err := batch.Commit(ctx); err != nil {
+				return err
+			}
+			batch = r.client.Batch()
+			count = 0
+		}
+	}
+
+	if count > 0 {
+		_, err := batch.Commit(ctx)
+		return err
+	}
+
+	return nil
+}
+
+// normalizeHistory fills in defaults for records written before a field existed
+func normalizeHistory(history *models.History) {
+	if history.Type == "" {
+		history.Type = models.HistoryTypeTrigger
+	}
+	if history.State == "" {
+		history.State = models.TriggerStateSent
+	}
+}
+
+// DeleteHistoryForUser permanently deletes every history record a user sent or received
+func (r *HistoryRepository) DeleteHistoryForUser(ctx context.Context, userID string) (int, error) {
+	ctx, op := observe(ctx, "history.DeleteHistoryForUser")
+	defer op.End()
+
+	total := 0
+	for _, field := range []string{"senderId", "receiverId"} {
+		deleted, err := deleteQuery(ctx, r.client, r.client.Collection("history").Where(field, "==", userID))
+		total += deleted
+		if err != nil {
+			return total, err
+		}
+	}
+	return total, nil
+}
+
+// GetAllHistoryForUser retrieves every history record a user sent or received, including ones they hid, oldest first
+func (r *HistoryRepository) GetAllHistoryForUser(ctx context.Context, userID string) ([]*models.History, error) {
+	ctx, op := observe(ctx, "history.GetAllHistoryForUser")
+	defer op.End()
+
+	var history []*models.History
+
+	for _, field := range []string{"senderId", "receiverId"} {
+		iter := r.client.Collection("history").
+			Where(field, "==", userID).
+			Documents(ctx)
+
+		for {
+			doc, err := iter.Next()
+			if err == iterator.Done {
+				break
+			}
+			if err != nil {
+				return nil, err
+			}
+
+			var h models.History
+			if err := doc.DataTo(&h); err != nil {
+				continue
+			}
+			normalizeHistory(&h)
+			history = append(history, &h)
+		}
+	}
+
+	sort.Slice(history, func(i, j int) bool {
+		return history[i].TriggeredAt.Before(history[j].TriggeredAt)
+	})
+	op.SetResultCount(len(history))
+	return history, nil
+}
@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/yourusername/rbd-service/internal/metrics"
+	"github.com/yourusername/rbd-service/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = tracing.Tracer("github.com/yourusername/rbd-service/internal/repository")
+
+// collections maps each operation prefix to the main Firestore collection behind it
+var collections = map[string]string{
+	"users":     "users",
+	"friends":   "friends",
+	"history":   "history",
+	"cooldowns": "cooldowns",
+	"stats":     "stats",
+	"audit":     "auditLog",
+	"deletions": "deletionJobs",
+	"exports":   "exports",
+	"reports":   "reports",
+}
+
+// call is one observed repository call
+type call struct {
+	span      trace.Span
+	operation string
+	start     time.Time
+}
+
+// observe starts a span for a repository operation ("<prefix>.<Method>").
+// Use as `ctx, op := observe(ctx, "friends.GetFriendship")` followed by `defer op.End()`.
+func observe(ctx context.Context, operation string) (context.Context, *call) {
+	prefix, _, _ := strings.Cut(operation, ".")
+	ctx, span := tracer.Start(ctx, operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", "firestore"),
+			attribute.String("db.operation", operation),
+			attribute.String("db.collection", collections[prefix]),
+		),
+	)
+	return ctx, &call{span: span, operation: operation, start: time.Now()}
+}
+
+// SetResultCount records how many documents the call returned or touched
+func (c *call) SetResultCount(n int) {
+	c.span.SetAttributes(attribute.Int("db.result_count", n))
+}
+
+// End finishes the span and records the call's latency
+func (c *call) End() {
+	metrics.ObserveRepository(c.operation, c.start)
+	c.span.End()
+}
@@ -0,0 +1,140 @@
+package repository
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+
+	"cloud.google.com/go/firestore"
+	"cloud.google.com/go/firestore/apiv1/firestorepb"
+	"google.golang.org/api/iterator"
+)
+
+// encodeCursor turns a document ID into an opaque pagination cursor
+func encodeCursor(docID string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(docID))
+}
+
+// decodeCursor turns an opaque pagination cursor back into a document ID
+func decodeCursor(cursor string) (string, error) {
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil || len(b) == 0 {
+		return "", errors.New("invalid cursor")
+	}
+	return string(b), nil
+}
+
+// trimPage cuts a result fetched with limit+1 down to limit and returns the cursor for the next
+// page, or an empty cursor when the extra item wasn't there
+func trimPage[T any](items []T, limit int, key func(T) string) ([]T, string) {
+	if len(items) <= limit {
+		return items, ""
+	}
+	items = items[:limit]
+	return items, encodeCursor(key(items[limit-1]))
+}
+
+// countQuery runs a server-side count aggregation instead of reading every document
+func countQuery(ctx context.Context, q firestore.Query) (int, error) {
+	result, err := q.NewAggregationQuery().WithCount("count").Get(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	value, ok := result["count"].(*firestorepb.Value)
+	if !ok {
+		return 0, fmt.Errorf("unexpected count result: %v", result["count"])
+	}
+
+	return int(value.GetIntegerValue()), nil
+}
+
+// deleteQuery deletes every document matching the query in batches and returns how many were deleted
+func deleteQuery(ctx context.Context, client *firestore.Client, q firestore.Query) (int, error) {
+	iter := q.Documents(ctx)
+	defer iter.Stop()
+
+	batch := client.Batch()
+	count := 0
+	deleted := 0
+
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return deleted, err
+		}
+
+		batch.Delete(doc.Ref)
+		count++
+
+		// Firestore batch limit is 500
+		if count >= 500 {
+			if _, err := batch.Commit(ctx); err != nil {
+				return deleted, err
+			}
+			deleted += count
+			batch = client.Batch()
+			count = 0
+		}
+	}
+
+	if count > 0 {
+		if _, err := batch.Commit(ctx); err != nil {
+			return deleted, err
+		}
+		deleted += count
+	}
+
+	return deleted, nil
+}
+
+// updateQuery applies the updates returned by fn to every document matching the query, in batches,
+// and returns how many were updated. fn returns nil when a document needs no changes.
+func updateQuery(ctx context.Context, client *firestore.Client, q firestore.Query, fn func(doc *firestore.DocumentSnapshot) []firestore.Update) (int, error) {
+	iter := q.Documents(ctx)
+	defer iter.Stop()
+
+	batch := client.Batch()
+	count := 0
+	updated := 0
+
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return updated, err
+		}
+
+		updates := fn(doc)
+		if len(updates) == 0 {
+			continue
+		}
+		batch.Update(doc.Ref, updates)
+		count++
+
+		// Firestore batch limit is 500
+		if count >= 500 {
+			if _, err := batch.Commit(ctx); err != nil {
+				return updated, err
+			}
+			updated += count
+			batch = client.Batch()
+			count = 0
+		}
+	}
+
+	if count > 0 {
+		if _, err := batch.Commit(ctx); err != nil {
+			return updated, err
+		}
+		updated += count
+	}
+
+	return updated, nil
+}
@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	for _, id := range []string{"abc123", "user/with:odd chars", "ünïcode"} {
+		got, err := decodeCursor(encodeCursor(id))
+		if err != nil || got != id {
+			t.Fatalf("decodeCursor(encodeCursor(%q)) = %q, %v", id, got, err)
+		}
+	}
+
+	for _, cursor := range []string{"", "not base64!"} {
+		if _, err := decodeCursor(cursor); err == nil || err.Error() != "invalid cursor" {
+			t.Fatalf("decodeCursor(%q): expected \"invalid cursor\", got %v", cursor, err)
+		}
+	}
+}
+
+func TestTrimPageWalksAllItems(t *testing.T) {
+	all := []string{"a", "b", "c", "d", "e"}
+	const limit = 2
+
+	// Simulate the repositories: fetch limit+1 after the cursor, then trim
+	var seen []string
+	cursor := ""
+	for pages := 0; ; pages++ {
+		if pages > len(all) {
+			t.Fatal("pagination never ended")
+		}
+		start := 0
+		if cursor != "" {
+			lastID, err := decodeCursor(cursor)
+			if err != nil {
+				t.Fatal(err)
+			}
+			start = slices.Index(all, lastID) + 1
+		}
+		fetched := all[start:min(start+limit+1, len(all))]
+
+		page, next := trimPage(fetched, limit, func(s string) string { return s })
+		if len(page) > limit {
+			t.Fatalf("page of %d items exceeds the limit of %d", len(page), limit)
+		}
+		seen = append(seen, page...)
+		if next == "" {
+			break
+		}
+		cursor = next
+	}
+
+	if !slices.Equal(seen, all) {
+		t.Fatalf("walked %v, expected %v", seen, all)
+	}
+}
+
+func TestTrimPageExactFit(t *testing.T) {
+	page, next := trimPage([]string{"a", "b"}, 2, func(s string) string { return s })
+	if len(page) != 2 || next != "" {
+		t.Fatalf("a full last page must not return a cursor, got %v %q", page, next)
+	}
+}
@@ -0,0 +1,283 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/yourusername/rbd-service/internal/config"
+	"github.com/yourusername/rbd-service/internal/models"
+	"google.golang.org/api/iterator"
+)
+
+type ReportRepository struct {
+	client *firestore.Client
+}
+
+func NewReportRepository() *ReportRepository {
+	return &ReportRepository{
+		client: config.FirestoreClient,
+	}
+}
+
+// CreateReport stores a new open report
+func (r *ReportRepository) CreateReport(ctx context.Context, report *models.Report) error {
+	ctx, op := observe(ctx, "reports.CreateReport")
+	defer op.End()
+
+	ref := r.client.Collection("reports").NewDoc()
+	report.ReportID = ref.ID
+	report.Status = models.ReportOpen
+	report.CreatedAt = time.Now()
+	_, err := ref.Create(ctx, report)
+	return err
+}
+
+// GetReport retrieves a report by ID
+func (r *ReportRepository) GetReport(ctx context.Context, reportID string) (*models.Report, error) {
+	ctx, op := observe(ctx, "reports.GetReport")
+	defer op.End()
+
+	doc, err := r.client.Collection("reports").Doc(reportID).Get(ctx)
+	if err != nil {
+		return nil, errors.New("report not found")
+	}
+
+	var report models.Report
+	if err := doc.DataTo(&report); err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// HasPendingReport reports whether a reporter already has an unresolved report about a user
+func (r *ReportRepository) HasPendingReport(ctx context.Context, reporterID, reportedUserID string) (bool, error) {
+	ctx, op := observe(ctx, "reports.HasPendingReport")
+	defer op.End()
+
+	iter := r.client.Collection("reports").
+		Where("reporterId", "==", reporterID).
+		Where("reportedUserId", "==", reportedUserID).
+		Documents(ctx)
+
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+
+		status, _ := doc.DataAt("status")
+		if s, ok := status.(string); ok && models.ReportStatus(s).Pending() {
+			return true, nil
+		}
+	}
+}
+
+// CountRecentReporters counts distinct users with a pending report about a user filed since the given time
+func (r *ReportRepository) CountRecentReporters(ctx context.Context, reportedUserID string, since time.Time) (int, error) {
+	ctx, op := observe(ctx, "reports.CountRecentReporters")
+	defer op.End()
+
+	iter := r.client.Collection("reports").
+		Where("reportedUserId", "==", reportedUserID).
+		Where("createdAt", ">=", since).
+		Documents(ctx)
+
+	var reports []*models.Report
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return 0, err
+		}
+
+		var report models.Report
+		if err := doc.DataTo(&report); err != nil {
+			continue
+		}
+		reports = append(reports, &report)
+	}
+
+	op.SetResultCount(len(reports))
+	return models.PendingReporters(reports), nil
+}
+
+// ListByStatus returns reports with the given status, oldest first (the queue order)
+func (r *ReportRepository) ListByStatus(ctx context.Context, status models.ReportStatus, cursor string, limit int) ([]*models.Report, string, error) {
+	ctx, op := observe(ctx, "reports.ListByStatus")
+	defer op.End()
+
+	query := r.client.Collection("reports").
+		Where("status", "==", string(status)).
+		OrderBy("createdAt", firestore.Asc).
+		OrderBy(firestore.DocumentID, firestore.Asc)
+	return r.page(ctx, op, query, cursor, limit)
+}
+
+// ListByReporter returns the reports a user filed, newest first
+func (r *ReportRepository) ListByReporter(ctx context.Context, reporterID, cursor string, limit int) ([]*models.Report, string, error) {
+	ctx, op := observe(ctx, "reports.ListByReporter")
+	defer op.End()
+
+	query := r.client.Collection("reports").
+		Where("reporterId", "==", reporterID).
+		OrderBy("createdAt", firestore.Desc).
+		OrderBy(firestore.DocumentID, firestore.Desc)
+	return r.page(ctx, op, query, cursor, limit)
+}
+
+// page runs an ordered report query starting after the report the cursor points to
+func (r *ReportRepository) page(ctx context.Context, op *call, query firestore.Query, cursor string, limit int) ([]*models.Report, string, error) {
+	if cursor != "" {
+		lastID, err := decodeCursor(cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		lastDoc, err := r.client.Collection("reports").Doc(lastID).Get(ctx)
+		if err != nil {
+			return nil, "", errors.New("invalid cursor")
+		}
+		query = query.StartAfter(lastDoc)
+	}
+
+	// Fetch one extra report to know whether another page exists
+	iter := query.Limit(limit + 1).Documents(ctx)
+
+	reports := []*models.Report{}
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, "", err
+		}
+
+		var report models.Report
+		if err := doc.DataTo(&report); err != nil {
+			continue
+		}
+		reports = append(reports, &report)
+	}
+
+	reports, nextCursor := trimPage(reports, limit, func(r *models.Report) string { return r.ReportID })
+
+	op.SetResultCount(len(reports))
+	return reports, nextCursor, nil
+}
+
+// ClaimReport assigns an open report to a moderator.
+// A report already in review can be taken over once its claim is older than staleAfter.
+func (r *ReportRepository) ClaimReport(ctx context.Context, reportID, moderatorID string, staleAfter time.Duration) (*models.Report, error) {
+	ctx, op := observe(ctx, "reports.ClaimReport")
+	defer op.End()
+
+	ref := r.client.Collection("reports").Doc(reportID)
+
+	var report models.Report
+	err := r.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		doc, err := tx.Get(ref)
+		if err != nil {
+			return errors.New("report not found")
+		}
+		if err := doc.DataTo(&report); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		switch report.Status {
+		case models.ReportOpen:
+		case models.ReportInReview:
+			stale := report.ClaimedAt != nil && now.Sub(*report.ClaimedAt) > staleAfter
+			if report.ClaimedBy != moderatorID && !stale {
+				return errors.New("report already claimed")
+			}
+		default:
+			return errors.New("report already resolved")
+		}
+
+		report.Status = models.ReportInReview
+		report.ClaimedBy = moderatorID
+		report.ClaimedAt = &now
+		return tx.Update(ref, []firestore.Update{
+			{Path: "status", Value: report.Status},
+			{Path: "claimedBy", Value: moderatorID},
+			{Path: "claimedAt", Value: now},
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &report, nil
+}
+
+// ResolveReport closes a report the moderator has claimed
+func (r *ReportRepository) ResolveReport(ctx context.Context, reportID, moderatorID string, status models.ReportStatus, action models.ModerationAction, note string) error {
+	ctx, op := observe(ctx, "reports.ResolveReport")
+	defer op.End()
+
+	ref := r.client.Collection("reports").Doc(reportID)
+
+	return r.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		doc, err := tx.Get(ref)
+		if err != nil {
+			return errors.New("report not found")
+		}
+		var report models.Report
+		if err := doc.DataTo(&report); err != nil {
+			return err
+		}
+
+		if report.Status != models.ReportInReview {
+			if report.Status == models.ReportOpen {
+				return errors.New("report must be claimed first")
+			}
+			return errors.New("report already resolved")
+		}
+		if report.ClaimedBy != moderatorID {
+			return errors.New("report already claimed")
+		}
+
+		return tx.Update(ref, []firestore.Update{
+			{Path: "status", Value: status},
+			{Path: "action", Value: action},
+			{Path: "resolutionNote", Value: note},
+			{Path: "resolvedBy", Value: moderatorID},
+			{Path: "resolvedAt", Value: time.Now()},
+		})
+	})
+}
+
+// DeleteReportsAbout deletes every report naming the user; their snapshot holds the user's profile and history
+func (r *ReportRepository) DeleteReportsAbout(ctx context.Context, userID string) (int, error) {
+	ctx, op := observe(ctx, "reports.DeleteReportsAbout")
+	defer op.End()
+
+	return deleteQuery(ctx, r.client, r.client.Collection("reports").Where("reportedUserId", "==", userID))
+}
+
+// AnonymizeReportsBy replaces the user's ID in reports they filed, claimed or resolved.
+// The reports stay so moderation of the other user isn't lost.
+func (r *ReportRepository) AnonymizeReportsBy(ctx context.Context, userID string) (int, error) {
+	ctx, op := observe(ctx, "reports.AnonymizeReportsBy")
+	defer op.End()
+
+	total := 0
+	for _, field := range []string{"reporterId", "claimedBy", "resolvedBy"} {
+		updated, err := updateQuery(ctx, r.client, r.client.Collection("reports").Where(field, "==", userID), func(doc *firestore.DocumentSnapshot) []firestore.Update {
+			return []firestore.Update{{Path: field, Value: models.DeletedUserID}}
+		})
+		total += updated
+		if err != nil {
+			return total, err
+		}
+	}
+	return total, nil
+}
@@ -0,0 +1,227 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/yourusername/rbd-service/internal/config"
+	"github.com/yourusername/rbd-service/internal/models"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type StatsRepository struct {
+	client *firestore.Client
+}
+
+func NewStatsRepository() *StatsRepository {
+	return &StatsRepository{
+		client: config.FirestoreClient,
+	}
+}
+
+// userStatsRef returns the per-user aggregate document
+func (r *StatsRepository) userStatsRef(userID string) *firestore.DocumentRef {
+	return r.client.Collection("stats").Doc(userID)
+}
+
+// friendStatsRef returns the aggregate document for one user's view of one friend
+func (r *StatsRepository) friendStatsRef(userID, friendUserID string) *firestore.DocumentRef {
+	return r.userStatsRef(userID).Collection("friends").Doc(friendUserID)
+}
+
+// RecordTrigger updates the sender's and receiver's aggregates for a new trigger
+func (r *StatsRepository) RecordTrigger(ctx context.Context, senderID, receiverID string, at time.Time) error {
+	ctx, op := observe(ctx, "stats.RecordTrigger")
+	defer op.End()
+
+	refs := []*firestore.DocumentRef{
+		r.userStatsRef(senderID),
+		r.userStatsRef(receiverID),
+		r.friendStatsRef(senderID, receiverID),
+		r.friendStatsRef(receiverID, senderID),
+	}
+
+	return r.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		stats, err := r.getAll(tx, refs)
+		if err != nil {
+			return err
+		}
+
+		stats[0].AddTrigger(at, true, receiverID)
+		stats[1].AddTrigger(at, false, senderID)
+		stats[2].AddTrigger(at, true, "")
+		stats[3].AddTrigger(at, false, "")
+
+		for i, ref := range refs {
+			if err := tx.Set(ref, stats[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// RecordResponse updates the responder's aggregates with how long they took to answer a trigger
+func (r *StatsRepository) RecordResponse(ctx context.Context, responderID, senderID string, took time.Duration) error {
+	ctx, op := observe(ctx, "stats.RecordResponse")
+	defer op.End()
+
+	refs := []*firestore.DocumentRef{
+		r.userStatsRef(responderID),
+		r.friendStatsRef(responderID, senderID),
+	}
+
+	return r.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		stats, err := r.getAll(tx, refs)
+		if err != nil {
+			return err
+		}
+
+		for i, ref := range refs {
+			stats[i].AddResponse(took)
+			if err := tx.Set(ref, stats[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// GetUserStats retrieves a user's overall aggregate (empty if they have no activity yet)
+func (r *StatsRepository) GetUserStats(ctx context.Context, userID string) (*models.TriggerStats, error) {
+	ctx, op := observe(ctx, "stats.GetUserStats")
+	defer op.End()
+
+	return r.get(ctx, r.userStatsRef(userID))
+}
+
+// GetFriendStats retrieves a user's aggregate for one friend (empty if they have no activity yet)
+func (r *StatsRepository) GetFriendStats(ctx context.Context, userID, friendUserID string) (*models.TriggerStats, error) {
+	ctx, op := observe(ctx, "stats.GetFriendStats")
+	defer op.End()
+
+	return r.get(ctx, r.friendStatsRef(userID, friendUserID))
+}
+
+// SetStats overwrites aggregates wholesale (used when rebuilding from history)
+func (r *StatsRepository) SetStats(ctx context.Context, userID string, overall *models.TriggerStats, perFriend map[string]*models.TriggerStats) error {
+	ctx, op := observe(ctx, "stats.SetStats")
+	defer op.End()
+
+	batch := r.client.Batch()
+	count := 0
+
+	batch.Set(r.userStatsRef(userID), overall)
+	count++
+
+	for friendUserID, stats := range perFriend {
+		batch.Set(r.friendStatsRef(userID, friendUserID), stats)
+		count++
+
+		// Firestore batch limit is 500
+		if count >= 500 {
+			if _, err := batch.Commit(ctx); err != nil {
+				return err
+			}
+			batch = r.client.Batch()
+			count = 0
+		}
+	}
+
+	if count > 0 {
+		_, err := batch.Commit(ctx)
+		return err
+	}
+
+	return nil
+}
+
+func (r *StatsRepository) get(ctx context.Context, ref *firestore.DocumentRef) (*models.TriggerStats, error) {
+	doc, err := ref.Get(ctx)
+	if status.Code(err) == codes.NotFound {
+		return &models.TriggerStats{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var stats models.TriggerStats
+	if err := doc.DataTo(&stats); err != nil {
+		return nil, err
+	}
+
+	return &stats, nil
+}
+
+// getAll reads aggregates inside a transaction, treating missing documents as empty
+func (r *StatsRepository) getAll(tx *firestore.Transaction, refs []*firestore.DocumentRef) ([]*models.TriggerStats, error) {
+	docs, err := tx.GetAll(refs)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := make([]*models.TriggerStats, len(docs))
+	for i, doc := range docs {
+		stats[i] = &models.TriggerStats{}
+		if !doc.Exists() {
+			continue
+		}
+		if err := doc.DataTo(stats[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	return stats, nil
+}
+
+// DeleteStatsForUser deletes a user's aggregates and removes them from their friends' aggregates
+func (r *StatsRepository) DeleteStatsForUser(ctx context.Context, userID string) (int, error) {
+	ctx, op := observe(ctx, "stats.DeleteStatsForUser")
+	defer op.End()
+
+	iter := r.userStatsRef(userID).Collection("friends").Documents(ctx)
+	defer iter.Stop()
+
+	batch := r.client.Batch()
+	count := 0
+	deleted := 0
+
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return deleted, err
+		}
+		friendUserID := doc.Ref.ID
+
+		// Drop the friend's view of this user along with this user's view of the friend
+		batch.Delete(r.friendStatsRef(friendUserID, userID))
+		batch.Set(r.userStatsRef(friendUserID), map[string]interface{}{
+			"friends": map[string]interface{}{userID: firestore.Delete},
+		}, firestore.MergeAll)
+		batch.Delete(doc.Ref)
+		count += 3
+		deleted++
+
+		// Firestore batch limit is 500
+		if count >= 498 {
+			if _, err := batch.Commit(ctx); err != nil {
+				return deleted, err
+			}
+			batch = r.client.Batch()
+			count = 0
+		}
+	}
+
+	batch.Delete(r.userStatsRef(userID))
+	if _, err := batch.Commit(ctx); err != nil {
+		return deleted, err
+	}
+
+	return deleted + 1, nil
+}
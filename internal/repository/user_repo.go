@@ -3,12 +3,16 @@ package repository
 import (
 	"context"
 	"errors"
+	"fmt"
 	"strings"
+	"time"
 
 	"cloud.google.com/go/firestore"
 	"github.com/yourusername/rbd-service/internal/config"
 	"github.com/yourusername/rbd-service/internal/models"
 	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type UserRepository struct {
@@ -21,14 +25,147 @@ func NewUserRepository() *UserRepository {
 	}
 }
 
-// CreateUser creates a new user in Firestore
+// CreateUser creates a new user in Firestore together with a reservation of their lowercased username.
+// Both are written in one transaction, so two registrations of the same name (in any case) can't both succeed.
 func (r *UserRepository) CreateUser(ctx context.Context, user *models.User) error {
-	_, err := r.client.Collection("users").Doc(user.UserID).Set(ctx, user)
-	return err
+	ctx, op := observe(ctx, "users.CreateUser")
+	defer op.End()
+
+	user.UsernameLower = strings.ToLower(user.Username)
+	reservationRef := r.client.Collection("usernames").Doc(user.UsernameLower)
+	userRef := r.client.Collection("users").Doc(user.UserID)
+
+	return r.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		available, err := r.reservationAvailable(tx, reservationRef, user.UserID)
+		if err != nil {
+			return err
+		}
+		if !available {
+			return errors.New("username already taken")
+		}
+
+		reservation := models.UsernameReservation{
+			UsernameLower: user.UsernameLower,
+			UserID:        user.UserID,
+			ReservedAt:    time.Now(),
+		}
+		if err := tx.Set(reservationRef, reservation); err != nil {
+			return err
+		}
+		return tx.Create(userRef, user)
+	})
+}
+
+// ChangeUsername renames a user, moving their reservation to the new name in one transaction.
+// The old name stays held for the user until now+grace; renames are limited to one per cooloff.
+// Returns the updated user and the username it replaced.
+func (r *UserRepository) ChangeUsername(ctx context.Context, userID, newUsername string, cooloff, grace time.Duration) (*models.User, string, error) {
+	ctx, op := observe(ctx, "users.ChangeUsername")
+	defer op.End()
+
+	userRef := r.client.Collection("users").Doc(userID)
+	newLower := strings.ToLower(newUsername)
+	newRef := r.client.Collection("usernames").Doc(newLower)
+
+	var user models.User
+	var oldUsername string
+	err := r.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		doc, err := tx.Get(userRef)
+		if err != nil {
+			return errors.New("user not found")
+		}
+		if err := doc.DataTo(&user); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		if user.Username == newUsername {
+			return errors.New("username unchanged")
+		}
+		if user.UsernameChangedAt != nil && now.Before(user.UsernameChangedAt.Add(cooloff)) {
+			return fmt.Errorf("username_change_cooldown:%s", user.UsernameChangedAt.Add(cooloff).Format(time.RFC3339))
+		}
+
+		available, err := r.reservationAvailable(tx, newRef, userID)
+		if err != nil {
+			return err
+		}
+		if !available {
+			return errors.New("username already taken")
+		}
+
+		// All reads are done; now the writes
+		oldLower := user.UsernameLower
+		if oldLower == "" {
+			oldLower = strings.ToLower(user.Username)
+		}
+		if oldLower != newLower {
+			heldUntil := now.Add(grace)
+			if err := tx.Set(r.client.Collection("usernames").Doc(oldLower), map[string]interface{}{
+				"usernameLower": oldLower,
+				"userId":        userID,
+				"releasedAt":    now,
+				"heldUntil":     heldUntil,
+			}, firestore.MergeAll); err != nil {
+				return err
+			}
+		}
+		if err := tx.Set(newRef, models.UsernameReservation{
+			UsernameLower: newLower,
+			UserID:        userID,
+			ReservedAt:    now,
+		}); err != nil {
+			return err
+		}
+
+		if err := tx.Create(userRef.Collection("usernameHistory").NewDoc(), models.UsernameChange{
+			OldUsername: user.Username,
+			NewUsername: newUsername,
+			ChangedAt:   now,
+		}); err != nil {
+			return err
+		}
+
+		oldUsername = user.Username
+		user.Username = newUsername
+		user.UsernameLower = newLower
+		user.UsernameChangedAt = &now
+		return tx.Update(userRef, []firestore.Update{
+			{Path: "username", Value: newUsername},
+			{Path: "usernameLower", Value: newLower},
+			{Path: "usernameChangedAt", Value: now},
+		})
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	return &user, oldUsername, nil
+}
+
+// reservationAvailable checks inside a transaction whether a username reservation can be claimed by userID
+func (r *UserRepository) reservationAvailable(tx *firestore.Transaction, ref *firestore.DocumentRef, userID string) (bool, error) {
+	doc, err := tx.Get(ref)
+	if status.Code(err) == codes.NotFound {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	var reservation models.UsernameReservation
+	if err := doc.DataTo(&reservation); err != nil {
+		return false, err
+	}
+
+	return reservation.AvailableTo(userID, time.Now()), nil
 }
 
 // GetUserByID retrieves a user by their ID
 func (r *UserRepository) GetUserByID(ctx context.Context, userID string) (*models.User, error) {
+	ctx, op := observe(ctx, "users.GetUserByID")
+	defer op.End()
+
 	doc, err := r.client.Collection("users").Doc(userID).Get(ctx)
 	if err != nil {
 		return nil, err
@@ -42,10 +179,53 @@ func (r *UserRepository) GetUserByID(ctx context.Context, userID string) (*model
 	return &user, nil
 }
 
-// GetUserByUsername retrieves a user by their username
+// GetUsersByIDs fetches several users in one round trip, keyed by user ID.
+// Users that don't exist are left out of the map.
+func (r *UserRepository) GetUsersByIDs(ctx context.Context, userIDs []string) (map[string]*models.User, error) {
+	ctx, op := observe(ctx, "users.GetUsersByIDs")
+	defer op.End()
+
+	users := make(map[string]*models.User, len(userIDs))
+	if len(userIDs) == 0 {
+		return users, nil
+	}
+
+	refs := make([]*firestore.DocumentRef, 0, len(userIDs))
+	for _, id := range userIDs {
+		refs = append(refs, r.client.Collection("users").Doc(id))
+	}
+	docs, err := r.client.GetAll(ctx, refs)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, doc := range docs {
+		if !doc.Exists() {
+			continue
+		}
+		var user models.User
+		if err := doc.DataTo(&user); err != nil {
+			return nil, err
+		}
+		users[doc.Ref.ID] = &user
+	}
+	op.SetResultCount(len(users))
+
+	return users, nil
+}
+
+// GetUserByUsername retrieves a user by their username (case-insensitive)
 func (r *UserRepository) GetUserByUsername(ctx context.Context, username string) (*models.User, error) {
-	iter := r.client.Collection("users").Where("username", "==", username).Limit(1).Documents(ctx)
+	ctx, op := observe(ctx, "users.GetUserByUsername")
+	defer op.End()
+
+	iter := r.client.Collection("users").Where("usernameLower", "==", strings.ToLower(username)).Limit(1).Documents(ctx)
 	doc, err := iter.Next()
+	if err == iterator.Done {
+		// Users created before usernameLower existed can still be found by exact name
+		iter = r.client.Collection("users").Where("username", "==", username).Limit(1).Documents(ctx)
+		doc, err = iter.Next()
+	}
 	if err == iterator.Done {
 		return nil, errors.New("user not found")
 	}
@@ -63,34 +243,301 @@ func (r *UserRepository) GetUserByUsername(ctx context.Context, username string)
 
 // UpdateFCMToken updates the user's FCM token
 func (r *UserRepository) UpdateFCMToken(ctx context.Context, userID, fcmToken string) error {
+	ctx, op := observe(ctx, "users.UpdateFCMToken")
+	defer op.End()
+
 	_, err := r.client.Collection("users").Doc(userID).Update(ctx, []firestore.Update{
 		{Path: "fcmToken", Value: fcmToken},
 	})
 	return err
 }
 
+// UpdatePasswordHash replaces the user's password hash
+func (r *UserRepository) UpdatePasswordHash(ctx context.Context, userID, passwordHash string) error {
+	ctx, op := observe(ctx, "users.UpdatePasswordHash")
+	defer op.End()
+
+	_, err := r.client.Collection("users").Doc(userID).Update(ctx, []firestore.Update{
+		{Path: "passwordHash", Value: passwordHash},
+	})
+	return err
+}
+
+// SetRecoveryCodes replaces the user's recovery code hashes
+func (r *UserRepository) SetRecoveryCodes(ctx context.Context, userID string, codeHashes []string) error {
+	ctx, op := observe(ctx, "users.SetRecoveryCodes")
+	defer op.End()
+
+	_, err := r.client.Collection("users").Doc(userID).Update(ctx, []firestore.Update{
+		{Path: "recoveryCodeHashes", Value: codeHashes},
+	})
+	return err
+}
+
+// ResetPasswordWithRecoveryCode consumes a recovery code and sets a new password hash in one transaction.
+// Returns false if the code isn't one of the user's unused codes.
+func (r *UserRepository) ResetPasswordWithRecoveryCode(ctx context.Context, userID, codeHash, passwordHash string) (bool, error) {
+	ctx, op := observe(ctx, "users.ResetPasswordWithRecoveryCode")
+	defer op.End()
+
+	userRef := r.client.Collection("users").Doc(userID)
+
+	consumed := false
+	err := r.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		consumed = false
+
+		doc, err := tx.Get(userRef)
+		if err != nil {
+			return err
+		}
+		var user models.User
+		if err := doc.DataTo(&user); err != nil {
+			return err
+		}
+
+		for _, h := range user.RecoveryCodeHashes {
+			if h == codeHash {
+				consumed = true
+				break
+			}
+		}
+		if !consumed {
+			return nil
+		}
+
+		return tx.Update(userRef, []firestore.Update{
+			{Path: "recoveryCodeHashes", Value: firestore.ArrayRemove(codeHash)},
+			{Path: "passwordHash", Value: passwordHash},
+		})
+	})
+
+	return consumed, err
+}
+
 // UpdateMuteAll updates the user's mute all setting
 func (r *UserRepository) UpdateMuteAll(ctx context.Context, userID string, mutedAll bool) error {
+	ctx, op := observe(ctx, "users.UpdateMuteAll")
+	defer op.End()
+
 	_, err := r.client.Collection("users").Doc(userID).Update(ctx, []firestore.Update{
 		{Path: "mutedAll", Value: mutedAll},
 	})
 	return err
 }
 
-// SearchUsersByUsername searches for users by username (case-insensitive prefix match)
-func (r *UserRepository) SearchUsersByUsername(ctx context.Context, username string, limit int) ([]*models.User, error) {
-	// Validate search query to prevent scanning entire collection
-	if len(strings.TrimSpace(username)) < 2 {
-		return []*models.User{}, nil // Require at least 2 characters
+// UpdateProfile updates the user's display name and/or bio (nil leaves a field unchanged)
+func (r *UserRepository) UpdateProfile(ctx context.Context, userID string, displayName, bio *string) error {
+	ctx, op := observe(ctx, "users.UpdateProfile")
+	defer op.End()
+
+	var updates []firestore.Update
+	if displayName != nil {
+		updates = append(updates, firestore.Update{Path: "displayName", Value: *displayName})
+	}
+	if bio != nil {
+		updates = append(updates, firestore.Update{Path: "bio", Value: *bio})
 	}
-	
-	// Firestore doesn't support case-insensitive queries, so we'll get all users
-	// and filter in Go (fine for small user base, for production use Algolia/Elasticsearch)
+	if len(updates) == 0 {
+		return nil
+	}
+
+	_, err := r.client.Collection("users").Doc(userID).Update(ctx, updates)
+	return err
+}
+
+// UpdateAvatar sets the user's avatar URLs and blob key (empty values clear the avatar)
+func (r *UserRepository) UpdateAvatar(ctx context.Context, userID, avatarKey, avatarURL, avatarThumbURL string) error {
+	ctx, op := observe(ctx, "users.UpdateAvatar")
+	defer op.End()
+
+	_, err := r.client.Collection("users").Doc(userID).Update(ctx, []firestore.Update{
+		{Path: "avatarKey", Value: avatarKey},
+		{Path: "avatarUrl", Value: avatarURL},
+		{Path: "avatarThumbUrl", Value: avatarThumbURL},
+	})
+	return err
+}
+
+// SearchUsersByUsername searches for users by username (case-insensitive prefix match).
+// Results are ordered by username; returns the page and a cursor for the next page.
+func (r *UserRepository) SearchUsersByUsername(ctx context.Context, username, cursor string, limit int) ([]*models.User, string, error) {
+	ctx, op := observe(ctx, "users.SearchUsersByUsername")
+	defer op.End()
+
+	prefix := strings.ToLower(strings.TrimSpace(username))
+
+	// Require at least 2 characters to keep result sets meaningful
+	if len(prefix) < 2 {
+		return []*models.User{}, "", nil
+	}
+
+	// Range query on the normalized field: every string starting with prefix sorts in [prefix, prefix+\uf8ff)
+	query := r.client.Collection("users").
+		Where("usernameLower", ">=", prefix).
+		Where("usernameLower", "<", prefix+"\uf8ff").
+		OrderBy("usernameLower", firestore.Asc)
+
+	if cursor != "" {
+		last, err := decodeCursor(cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		query = query.StartAfter(last)
+	}
+
+	// Fetch one extra record to know whether another page exists
+	iter := query.Limit(limit + 1).Documents(ctx)
+
+	users := []*models.User{}
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, "", err
+		}
+
+		var user models.User
+		if err := doc.DataTo(&user); err != nil {
+			continue
+		}
+		users = append(users, &user)
+	}
+
+	users, nextCursor := trimPage(users, limit, func(u *models.User) string { return u.UsernameLower })
+
+	op.SetResultCount(len(users))
+	return users, nextCursor, nil
+}
+
+// ForEachUser streams every user in the collection to fn
+func (r *UserRepository) ForEachUser(ctx context.Context, fn func(user *models.User) error) error {
+	ctx, op := observe(ctx, "users.ForEachUser")
+	defer op.End()
+
 	iter := r.client.Collection("users").Documents(ctx)
-	
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		var user models.User
+		if err := doc.DataTo(&user); err != nil {
+			continue
+		}
+		if err := fn(&user); err != nil {
+			return err
+		}
+	}
+}
+
+// ReleaseUsernames deletes every username reservation held by a user, including names kept after renames
+func (r *UserRepository) ReleaseUsernames(ctx context.Context, userID string) (int, error) {
+	ctx, op := observe(ctx, "users.ReleaseUsernames")
+	defer op.End()
+
+	return deleteQuery(ctx, r.client, r.client.Collection("usernames").Where("userId", "==", userID))
+}
+
+// DeleteUser deletes a user document together with its rename history
+func (r *UserRepository) DeleteUser(ctx context.Context, userID string) (int, error) {
+	ctx, op := observe(ctx, "users.DeleteUser")
+	defer op.End()
+
+	userRef := r.client.Collection("users").Doc(userID)
+
+	deleted, err := deleteQuery(ctx, r.client, userRef.Collection("usernameHistory").Query)
+	if err != nil {
+		return deleted, err
+	}
+	if _, err := userRef.Delete(ctx); err != nil {
+		return deleted, err
+	}
+	return deleted + 1, nil
+}
+
+// GetUsernameHistory lists a user's past renames, oldest first
+func (r *UserRepository) GetUsernameHistory(ctx context.Context, userID string) ([]*models.UsernameChange, error) {
+	ctx, op := observe(ctx, "users.GetUsernameHistory")
+	defer op.End()
+
+	iter := r.client.Collection("users").Doc(userID).Collection("usernameHistory").
+		OrderBy("changedAt", firestore.Asc).
+		Documents(ctx)
+
+	changes := []*models.UsernameChange{}
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var change models.UsernameChange
+		if err := doc.DataTo(&change); err != nil {
+			continue
+		}
+		changes = append(changes, &change)
+	}
+
+	op.SetResultCount(len(changes))
+	return changes, nil
+}
+
+// SetRole changes a user's role
+func (r *UserRepository) SetRole(ctx context.Context, userID string, role models.Role) error {
+	ctx, op := observe(ctx, "users.SetRole")
+	defer op.End()
+
+	_, err := r.client.Collection("users").Doc(userID).Update(ctx, []firestore.Update{
+		{Path: "role", Value: role},
+	})
+	return err
+}
+
+// SetStatus changes a user's account status. until is nil for an open-ended status.
+func (r *UserRepository) SetStatus(ctx context.Context, userID string, status models.AccountStatus, reason string, until *time.Time, changedBy string) error {
+	ctx, op := observe(ctx, "users.SetStatus")
+	defer op.End()
+
+	var untilValue interface{} = firestore.Delete
+	if until != nil {
+		untilValue = *until
+	}
+	var reasonValue interface{} = firestore.Delete
+	if reason != "" {
+		reasonValue = reason
+	}
+
+	_, err := r.client.Collection("users").Doc(userID).Update(ctx, []firestore.Update{
+		{Path: "status", Value: status},
+		{Path: "statusReason", Value: reasonValue},
+		{Path: "statusUntil", Value: untilValue},
+		{Path: "statusChangedAt", Value: time.Now()},
+		{Path: "statusChangedBy", Value: changedBy},
+	})
+	return err
+}
+
+// GetExpiredSuspensions lists suspended users whose suspension ended at or before now
+func (r *UserRepository) GetExpiredSuspensions(ctx context.Context, now time.Time, limit int) ([]*models.User, error) {
+	ctx, op := observe(ctx, "users.GetExpiredSuspensions")
+	defer op.End()
+
+	iter := r.client.Collection("users").
+		Where("status", "==", string(models.AccountSuspended)).
+		Where("statusUntil", "<=", now).
+		Limit(limit).
+		Documents(ctx)
+
 	var users []*models.User
-	searchLower := strings.ToLower(username)
-	
 	for {
 		doc, err := iter.Next()
 		if err == iterator.Done {
@@ -104,16 +551,9 @@ func (r *UserRepository) SearchUsersByUsername(ctx context.Context, username str
 		if err := doc.DataTo(&user); err != nil {
 			continue
 		}
-
-		// Case-insensitive prefix match
-		usernameLower := strings.ToLower(user.Username)
-		if strings.HasPrefix(usernameLower, searchLower) {
-			users = append(users, &user)
-			if len(users) >= limit {
-				break
-			}
-		}
+		users = append(users, &user)
 	}
 
+	op.SetResultCount(len(users))
 	return users, nil
 }
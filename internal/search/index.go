@@ -0,0 +1,55 @@
+package search
+
+import (
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// Match is a single search hit
+type Match struct {
+	UserID   string
+	Username string
+	Score    int // Lower is better
+}
+
+// Index finds users by (possibly misspelled) username
+type Index interface {
+	// Upsert adds a user or updates their username
+	Upsert(userID, username string)
+	// Remove deletes a user from the index
+	Remove(userID string)
+	// Username returns the name a user is indexed under, if they're indexed
+	Username(userID string) (string, bool)
+	// Replace swaps the whole index contents (used when rebuilding from the user store)
+	Replace(usernames map[string]string)
+	// Search returns up to limit matches, best first
+	Search(query string, limit int) []Match
+	// Ready reports whether the index has been built and can serve searches
+	Ready() bool
+}
+
+var (
+	index Index
+	once  sync.Once
+)
+
+// GetIndex returns the singleton search index
+func GetIndex() Index {
+	once.Do(func() {
+		index = NewTrigramIndex()
+	})
+	return index
+}
+
+// normalize lowercases a username and strips everything but letters and digits,
+// so "Rem_Fan" and "remfan" compare equal
+func normalize(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,243 @@
+package search
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Match tiers, combined with edit distance into Match.Score
+const (
+	tierExact     = 0
+	tierPrefix    = 100
+	tierSubstring = 200
+	tierFuzzy     = 300
+)
+
+type entry struct {
+	username   string
+	normalized string
+	trigrams   []string
+}
+
+// TrigramIndex is an in-memory Index backed by a trigram posting list.
+// It supports exact, prefix, substring and edit-distance matching.
+type TrigramIndex struct {
+	mu       sync.RWMutex
+	entries  map[string]*entry              // userID -> entry
+	postings map[string]map[string]struct{} // trigram -> set of userIDs
+	ready    bool
+}
+
+// NewTrigramIndex creates an empty trigram index
+func NewTrigramIndex() *TrigramIndex {
+	return &TrigramIndex{
+		entries:  make(map[string]*entry),
+		postings: make(map[string]map[string]struct{}),
+	}
+}
+
+// Upsert adds a user or updates their username
+func (t *TrigramIndex) Upsert(userID, username string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.removeLocked(userID)
+	t.addLocked(userID, username)
+}
+
+// Remove deletes a user from the index
+func (t *TrigramIndex) Remove(userID string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.removeLocked(userID)
+}
+
+// Username returns the name a user is indexed under, if they're indexed
+func (t *TrigramIndex) Username(userID string) (string, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	e, ok := t.entries[userID]
+	if !ok {
+		return "", false
+	}
+	return e.username, true
+}
+
+// Replace swaps the whole index contents and marks the index ready.
+// Users upserted while the index was still being built are kept.
+func (t *TrigramIndex) Replace(usernames map[string]string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	pending := t.entries
+	if t.ready {
+		pending = nil
+	}
+
+	t.entries = make(map[string]*entry, len(usernames))
+	t.postings = make(map[string]map[string]struct{})
+	for userID, username := range usernames {
+		t.addLocked(userID, username)
+	}
+	for userID, e := range pending {
+		t.removeLocked(userID)
+		t.addLocked(userID, e.username)
+	}
+	t.ready = true
+}
+
+// Ready reports whether the index has been built
+func (t *TrigramIndex) Ready() bool {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.ready
+}
+
+// Search returns up to limit matches, best first
+func (t *TrigramIndex) Search(query string, limit int) []Match {
+	q := normalize(query)
+	if len(q) < 2 || limit <= 0 {
+		return []Match{}
+	}
+
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	// Candidates share at least one trigram with the query. Short queries and
+	// queries whose trigrams all contain a typo fall back to scanning everything.
+	candidates := make(map[string]struct{})
+	for _, tg := range trigrams(q) {
+		for userID := range t.postings[tg] {
+			candidates[userID] = struct{}{}
+		}
+	}
+	if len(candidates) == 0 || len(q) < 3 {
+		for userID := range t.entries {
+			candidates[userID] = struct{}{}
+		}
+	}
+
+	// Allow more typos the longer the query is
+	maxDistance := 0
+	if len(q) > 5 {
+		maxDistance = 2
+	} else if len(q) > 3 {
+		maxDistance = 1
+	}
+
+	var matches []Match
+	for userID := range candidates {
+		e := t.entries[userID]
+		score, ok := scoreMatch(q, e.normalized, maxDistance)
+		if !ok {
+			continue
+		}
+		matches = append(matches, Match{UserID: userID, Username: e.username, Score: score})
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Score != matches[j].Score {
+			return matches[i].Score < matches[j].Score
+		}
+		return strings.ToLower(matches[i].Username) < strings.ToLower(matches[j].Username)
+	})
+
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches
+}
+
+func (t *TrigramIndex) addLocked(userID, username string) {
+	e := &entry{
+		username:   username,
+		normalized: normalize(username),
+	}
+	e.trigrams = trigrams(e.normalized)
+	t.entries[userID] = e
+
+	for _, tg := range e.trigrams {
+		if t.postings[tg] == nil {
+			t.postings[tg] = make(map[string]struct{})
+		}
+		t.postings[tg][userID] = struct{}{}
+	}
+}
+
+func (t *TrigramIndex) removeLocked(userID string) {
+	e, ok := t.entries[userID]
+	if !ok {
+		return
+	}
+	for _, tg := range e.trigrams {
+		delete(t.postings[tg], userID)
+		if len(t.postings[tg]) == 0 {
+			delete(t.postings, tg)
+		}
+	}
+	delete(t.entries, userID)
+}
+
+// scoreMatch ranks how well a normalized username matches a normalized query
+func scoreMatch(q, name string, maxDistance int) (int, bool) {
+	switch {
+	case name == q:
+		return tierExact, true
+	case strings.HasPrefix(name, q):
+		return tierPrefix + len(name) - len(q), true
+	case strings.Contains(name, q):
+		return tierSubstring + len(name) - len(q), true
+	}
+
+	// Compare against the full name and against its leading part of the same length,
+	// so a typo in the middle of a prefix still matches longer names
+	distance := levenshtein(q, name)
+	if len(name) > len(q) {
+		if d := levenshtein(q, name[:len(q)]); d < distance {
+			distance = d
+		}
+	}
+	if distance > maxDistance {
+		return 0, false
+	}
+	return tierFuzzy + distance, true
+}
+
+// trigrams returns the distinct 3-character substrings of s, padded so short names still index
+func trigrams(s string) []string {
+	padded := " " + s + " "
+	seen := make(map[string]struct{})
+	var out []string
+	for i := 0; i+3 <= len(padded); i++ {
+		tg := padded[i : i+3]
+		if _, ok := seen[tg]; ok {
+			continue
+		}
+		seen[tg] = struct{}{}
+		out = append(out, tg)
+	}
+	return out
+}
+
+// levenshtein returns the edit distance between two strings
+func levenshtein(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+
+	return prev[len(b)]
+}
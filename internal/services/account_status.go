@@ -0,0 +1,157 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/yourusername/rbd-service/internal/audit"
+	"github.com/yourusername/rbd-service/internal/health"
+	"github.com/yourusername/rbd-service/internal/metrics"
+	"github.com/yourusername/rbd-service/internal/models"
+	"github.com/yourusername/rbd-service/internal/repository"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// accountStatusTTL bounds how long another instance keeps honouring a session after a suspension
+	accountStatusTTL = 30 * time.Second
+	// suspensionSweepInterval is how often expired suspensions are lifted
+	suspensionSweepInterval = time.Minute
+)
+
+type cachedStatus struct {
+	restriction *models.AccountRestriction
+	fetchedAt   time.Time
+}
+
+// accountStatusCache keeps recent account restrictions so AuthMiddleware doesn't read the user on every request
+type accountStatusCache struct {
+	mu       sync.Mutex
+	entries  map[string]cachedStatus
+	userRepo *repository.UserRepository
+}
+
+var (
+	statusCache     *accountStatusCache
+	statusCacheOnce sync.Once
+)
+
+func getAccountStatusCache() *accountStatusCache {
+	statusCacheOnce.Do(func() {
+		statusCache = &accountStatusCache{
+			entries:  make(map[string]cachedStatus),
+			userRepo: repository.NewUserRepository(),
+		}
+	})
+	return statusCache
+}
+
+// GetAccountRestriction returns the suspension or ban in effect for a user, or nil if they may use the service.
+// Results are cached briefly; InvalidateAccountStatus forces a fresh read.
+func GetAccountRestriction(ctx context.Context, userID string) (*models.AccountRestriction, error) {
+	cache := getAccountStatusCache()
+	now := time.Now()
+
+	cache.mu.Lock()
+	entry, ok := cache.entries[userID]
+	cache.mu.Unlock()
+	if ok && now.Sub(entry.fetchedAt) < accountStatusTTL {
+		if entry.restriction != nil && entry.restriction.Until != nil && !now.Before(*entry.restriction.Until) {
+			return nil, nil
+		}
+		return entry.restriction, nil
+	}
+
+	user, err := cache.userRepo.GetUserByID(ctx, userID)
+	if status.Code(err) == codes.NotFound {
+		return nil, errors.New("user not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	restriction := user.Restriction(now)
+
+	cache.mu.Lock()
+	cache.entries[userID] = cachedStatus{restriction: restriction, fetchedAt: now}
+	// Drop stale entries so the cache doesn't grow with every user ever seen
+	if len(cache.entries) > 10000 {
+		for id, e := range cache.entries {
+			if now.Sub(e.fetchedAt) >= accountStatusTTL {
+				delete(cache.entries, id)
+			}
+		}
+	}
+	cache.mu.Unlock()
+
+	return restriction, nil
+}
+
+// InvalidateAccountStatus forgets the cached status of a user (after suspending or reinstating them)
+func InvalidateAccountStatus(userID string) {
+	cache := getAccountStatusCache()
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	delete(cache.entries, userID)
+}
+
+// LiftExpiredSuspensions reinstates users whose suspension has ended
+func LiftExpiredSuspensions(ctx context.Context) (int, error) {
+	userRepo := repository.NewUserRepository()
+
+	users, err := userRepo.GetExpiredSuspensions(ctx, time.Now(), 200)
+	if err != nil {
+		return 0, err
+	}
+
+	lifted := 0
+	for _, user := range users {
+		if err := userRepo.SetStatus(ctx, user.UserID, models.AccountActive, "", nil, models.AuditActorSystem); err != nil {
+			slog.ErrorContext(ctx, "failed to lift suspension", "user_id", user.UserID, "error", err)
+			continue
+		}
+		lifted++
+
+		InvalidateAccountStatus(user.UserID)
+
+		audit.Record(ctx, &models.AuditEntry{
+			ActorID:      models.AuditActorSystem,
+			Action:       models.AuditSuspensionExpired,
+			TargetUserID: user.UserID,
+			Details: map[string]interface{}{
+				"until": *user.StatusUntil,
+			},
+			VisibleTo: []string{user.UserID},
+		})
+	}
+
+	return lifted, nil
+}
+
+// RunSuspensionSweep lifts expired suspensions periodically until ctx is cancelled
+func RunSuspensionSweep(ctx context.Context) {
+	health.WatchWorker("suspension_sweep", 3*suspensionSweepInterval)
+	ticker := time.NewTicker(suspensionSweepInterval)
+	defer ticker.Stop()
+
+	for {
+		start := time.Now()
+		lifted, err := LiftExpiredSuspensions(ctx)
+		metrics.ObserveJob("suspension_sweep", start, err)
+		health.Beat("suspension_sweep")
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to lift expired suspensions", "error", err)
+		} else if lifted > 0 {
+			slog.InfoContext(ctx, "lifted expired suspensions", "count", lifted)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,271 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/yourusername/rbd-service/internal/audit"
+	"github.com/yourusername/rbd-service/internal/models"
+	"github.com/yourusername/rbd-service/internal/repository"
+)
+
+// AdminService backs the moderator and admin API. Every action is written to the audit log.
+type AdminService struct {
+	userRepo     *repository.UserRepository
+	friendRepo   *repository.FriendRepository
+	cooldownRepo *repository.CooldownRepository
+	auditRepo    *repository.AuditRepository
+}
+
+func NewAdminService() *AdminService {
+	return &AdminService{
+		userRepo:     repository.NewUserRepository(),
+		friendRepo:   repository.NewFriendRepository(),
+		cooldownRepo: repository.NewCooldownRepository(),
+		auditRepo:    repository.NewAuditRepository(),
+	}
+}
+
+// GetRole returns a user's effective role
+func (s *AdminService) GetRole(ctx context.Context, userID string) (models.Role, error) {
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return "", errors.New("user not found")
+	}
+	return user.EffectiveRole(), nil
+}
+
+// LookupUser finds a user by ID, or by username if no user has that ID
+func (s *AdminService) LookupUser(ctx context.Context, actor *models.AuditActor, idOrUsername string) (*models.AdminUserView, error) {
+	user, err := s.userRepo.GetUserByID(ctx, idOrUsername)
+	if err != nil {
+		user, err = s.userRepo.GetUserByUsername(ctx, idOrUsername)
+		if err != nil {
+			return nil, errors.New("user not found")
+		}
+	}
+
+	friendCount, err := s.friendRepo.CountAcceptedFriends(ctx, user.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.audit(ctx, actor, models.AuditAdminViewUser, user.UserID, "", nil)
+
+	return &models.AdminUserView{
+		UserID:               user.UserID,
+		Username:             user.Username,
+		DisplayName:          user.DisplayName,
+		Role:                 user.EffectiveRole(),
+		Status:               user.EffectiveStatus(),
+		StatusReason:         user.StatusReason,
+		StatusUntil:          user.StatusUntil,
+		StatusChangedAt:      user.StatusChangedAt,
+		StatusChangedBy:      user.StatusChangedBy,
+		CreatedAt:            user.CreatedAt,
+		MutedAll:             user.MutedAll,
+		PushTokenRegistered:  user.FCMToken != "",
+		FriendCount:          friendCount,
+		ActiveSessions:       len(GetTokenStore().ListUserSessions(user.UserID)),
+		DeletionScheduledFor: user.DeletionScheduledFor,
+	}, nil
+}
+
+// GetFriendships lists every friendship and request involving a user
+func (s *AdminService) GetFriendships(ctx context.Context, actor *models.AuditActor, userID string) ([]*models.AdminFriendship, error) {
+	if _, err := s.userRepo.GetUserByID(ctx, userID); err != nil {
+		return nil, errors.New("user not found")
+	}
+
+	friendships, err := s.friendRepo.GetAllFriendships(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := []*models.AdminFriendship{}
+	for otherID, friendship := range friendships {
+		entry := &models.AdminFriendship{Friendship: friendship, OtherUserID: otherID}
+		if other, err := s.userRepo.GetUserByID(ctx, otherID); err == nil {
+			entry.OtherUsername = other.Username
+		}
+		result = append(result, entry)
+	}
+
+	s.audit(ctx, actor, models.AuditAdminViewFriendships, userID, "", nil)
+	return result, nil
+}
+
+// SuspendUser suspends an account until the given time (nil for indefinitely) and signs it out everywhere
+func (s *AdminService) SuspendUser(ctx context.Context, actor *models.AuditActor, userID, reason string, until *time.Time) error {
+	user, err := s.targetFor(ctx, actor, userID)
+	if err != nil {
+		return err
+	}
+	if until != nil && !until.After(time.Now()) {
+		return errors.New("suspension end must be in the future")
+	}
+
+	if user.EffectiveStatus() == models.AccountBanned {
+		return errors.New("user is banned")
+	}
+
+	if err := s.userRepo.SetStatus(ctx, userID, models.AccountSuspended, reason, until, actor.UserID); err != nil {
+		return err
+	}
+	revoked := s.restrict(userID)
+
+	details := map[string]interface{}{
+		"previousStatus":  string(user.EffectiveStatus()),
+		"sessionsRevoked": revoked,
+	}
+	if until != nil {
+		details["until"] = *until
+	}
+	s.audit(ctx, actor, models.AuditAdminSuspend, userID, reason, details)
+	return nil
+}
+
+// BanUser bans an account permanently and signs it out everywhere
+func (s *AdminService) BanUser(ctx context.Context, actor *models.AuditActor, userID, reason string) error {
+	user, err := s.targetFor(ctx, actor, userID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.userRepo.SetStatus(ctx, userID, models.AccountBanned, reason, nil, actor.UserID); err != nil {
+		return err
+	}
+	revoked := s.restrict(userID)
+
+	s.audit(ctx, actor, models.AuditAdminBan, userID, reason, map[string]interface{}{
+		"previousStatus":  string(user.EffectiveStatus()),
+		"sessionsRevoked": revoked,
+	})
+	return nil
+}
+
+// UnsuspendUser lifts a suspension or (for admins) a ban
+func (s *AdminService) UnsuspendUser(ctx context.Context, actor *models.AuditActor, userID, reason string) error {
+	user, err := s.targetFor(ctx, actor, userID)
+	if err != nil {
+		return err
+	}
+	if user.EffectiveStatus() == models.AccountActive {
+		return errors.New("user is not suspended")
+	}
+	if user.EffectiveStatus() == models.AccountBanned && actor.Role.Rank() < models.RoleAdmin.Rank() {
+		return errors.New("insufficient role")
+	}
+
+	if err := s.userRepo.SetStatus(ctx, userID, models.AccountActive, "", nil, actor.UserID); err != nil {
+		return err
+	}
+	InvalidateAccountStatus(userID)
+
+	s.audit(ctx, actor, models.AuditAdminUnsuspend, userID, reason, map[string]interface{}{
+		"previousStatus": string(user.EffectiveStatus()),
+	})
+	return nil
+}
+
+// RevokeSessions signs a user out of every session
+func (s *AdminService) RevokeSessions(ctx context.Context, actor *models.AuditActor, userID, reason string) (int, error) {
+	if _, err := s.targetFor(ctx, actor, userID); err != nil {
+		return 0, err
+	}
+
+	revoked := GetTokenStore().RevokeUserTokens(userID, "")
+
+	s.audit(ctx, actor, models.AuditAdminRevokeSessions, userID, reason, map[string]interface{}{
+		"sessionsRevoked": revoked,
+	})
+	return revoked, nil
+}
+
+// ClearCooldowns removes every trigger and response cooldown to or from a user
+func (s *AdminService) ClearCooldowns(ctx context.Context, actor *models.AuditActor, userID, reason string) (int, error) {
+	if _, err := s.targetFor(ctx, actor, userID); err != nil {
+		return 0, err
+	}
+
+	cleared, err := s.cooldownRepo.DeleteCooldownsForUser(ctx, userID)
+	if err != nil {
+		return cleared, err
+	}
+
+	s.audit(ctx, actor, models.AuditAdminClearCooldowns, userID, reason, map[string]interface{}{
+		"cooldownsCleared": cleared,
+	})
+	return cleared, nil
+}
+
+// SetRole changes a user's role. Admins can't change their own role, so there's always one admin left.
+func (s *AdminService) SetRole(ctx context.Context, actor *models.AuditActor, userID string, role models.Role, reason string) error {
+	if userID == actor.UserID {
+		return errors.New("cannot change your own role")
+	}
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return errors.New("user not found")
+	}
+
+	if err := s.userRepo.SetRole(ctx, userID, role); err != nil {
+		return err
+	}
+
+	s.audit(ctx, actor, models.AuditAdminSetRole, userID, reason, map[string]interface{}{
+		"previousRole": string(user.EffectiveRole()),
+		"role":         string(role),
+	})
+	return nil
+}
+
+// GetAuditLog returns audit entries newest first, optionally filtered by actor and/or target
+func (s *AdminService) GetAuditLog(ctx context.Context, actorID, targetUserID, cursor string, limit int) (*models.AuditLogResponse, error) {
+	entries, nextCursor, err := s.auditRepo.List(ctx, actorID, targetUserID, cursor, limit)
+	if err != nil {
+		return nil, err
+	}
+	return &models.AuditLogResponse{Entries: entries, NextCursor: nextCursor}, nil
+}
+
+// restrict applies a new suspension or ban right away: the user is signed out, and search
+// hides them because it checks account status at query time. Returns the number of sessions revoked.
+func (s *AdminService) restrict(userID string) int {
+	InvalidateAccountStatus(userID)
+	return GetTokenStore().RevokeUserTokens(userID, "")
+}
+
+// targetFor loads the user an action applies to. Staff can only act on users with a lower role than their own.
+func (s *AdminService) targetFor(ctx context.Context, actor *models.AuditActor, userID string) (*models.User, error) {
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+	if user.EffectiveRole().Rank() >= actor.Role.Rank() {
+		return nil, errors.New("insufficient role")
+	}
+	return user, nil
+}
+
+// audit records an action. Actions that change the target's account show up in their activity;
+// staff lookups and report handling stay internal.
+func (s *AdminService) audit(ctx context.Context, actor *models.AuditActor, action, targetUserID, reason string, details map[string]interface{}) {
+	entry := &models.AuditEntry{
+		ActorID:      actor.UserID,
+		ActorRole:    actor.Role,
+		Action:       action,
+		TargetUserID: targetUserID,
+		Reason:       reason,
+		Details:      details,
+	}
+	switch action {
+	case models.AuditAdminViewUser, models.AuditAdminViewFriendships, models.AuditReportClaim, models.AuditReportResolve:
+	default:
+		if targetUserID != "" {
+			entry.VisibleTo = []string{targetUserID}
+		}
+	}
+	audit.Record(ctx, entry)
+}
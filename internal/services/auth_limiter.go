@@ -0,0 +1,75 @@
+package services
+
+import (
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yourusername/rbd-service/internal/config"
+	"github.com/yourusername/rbd-service/internal/ratelimit"
+)
+
+// authLimiters guards the unauthenticated auth endpoints against brute force and mass sign-ups
+type authLimiters struct {
+	loginUser  *ratelimit.AttemptLimiter // Failed logins per username
+	loginIP    *ratelimit.AttemptLimiter // Failed logins per client IP
+	registerIP *ratelimit.AttemptLimiter // Registrations per client IP
+	resetUser  *ratelimit.AttemptLimiter // Failed recovery-code resets per username
+}
+
+var (
+	limiters     *authLimiters
+	limitersOnce sync.Once
+)
+
+// getAuthLimiters returns the shared auth limiters.
+// RATE_LIMIT_BACKEND=firestore shares state across instances; the default keeps it in memory.
+func getAuthLimiters() *authLimiters {
+	limitersOnce.Do(func() {
+		store := func(name string) ratelimit.AttemptStore {
+			if os.Getenv("RATE_LIMIT_BACKEND") == "firestore" && config.FirestoreClient != nil {
+				return ratelimit.NewFirestoreAttemptStore(config.FirestoreClient, "authAttempts_"+name)
+			}
+			return ratelimit.NewMemoryAttemptStore(24 * time.Hour)
+		}
+
+		limiters = &authLimiters{
+			loginUser: ratelimit.NewAttemptLimiter(store("loginUser"), ratelimit.AttemptPolicy{
+				Threshold:   5,
+				Window:      15 * time.Minute,
+				BaseLockout: 1 * time.Minute,
+				MaxLockout:  1 * time.Hour,
+			}),
+			loginIP: ratelimit.NewAttemptLimiter(store("loginIp"), ratelimit.AttemptPolicy{
+				Threshold:   20,
+				Window:      15 * time.Minute,
+				BaseLockout: 1 * time.Minute,
+				MaxLockout:  1 * time.Hour,
+			}),
+			registerIP: ratelimit.NewAttemptLimiter(store("registerIp"), ratelimit.AttemptPolicy{
+				Threshold:   5,
+				Window:      1 * time.Hour,
+				BaseLockout: 15 * time.Minute,
+				MaxLockout:  24 * time.Hour,
+			}),
+			resetUser: ratelimit.NewAttemptLimiter(store("resetUser"), ratelimit.AttemptPolicy{
+				Threshold:   5,
+				Window:      1 * time.Hour,
+				BaseLockout: 15 * time.Minute,
+				MaxLockout:  24 * time.Hour,
+			}),
+		}
+	})
+	return limiters
+}
+
+// usernameKey normalizes a username for limiter keys so case variations share a counter
+func usernameKey(username string) string {
+	return strings.ToLower(strings.TrimSpace(username))
+}
+
+// retryAfterSeconds rounds a lockout up to whole seconds
+func retryAfterSeconds(d time.Duration) int {
+	return int((d + time.Second - 1) / time.Second)
+}
@@ -5,16 +5,32 @@ import (
 	"crypto/rand"
 	"encoding/base64"
 	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
 	"time"
 
+	"github.com/yourusername/rbd-service/internal/audit"
 	"github.com/yourusername/rbd-service/internal/models"
 	"github.com/yourusername/rbd-service/internal/repository"
+	"github.com/yourusername/rbd-service/internal/search"
 	"github.com/yourusername/rbd-service/pkg/utils"
 	"golang.org/x/crypto/bcrypt"
 )
 
 type AuthService struct {
-	userRepo *repository.UserRepository
+	userRepo authUserStore
+}
+
+// authUserStore is the part of the user repository AuthService uses
+type authUserStore interface {
+	CreateUser(ctx context.Context, user *models.User) error
+	GetUserByID(ctx context.Context, userID string) (*models.User, error)
+	GetUserByUsername(ctx context.Context, username string) (*models.User, error)
+	UpdateFCMToken(ctx context.Context, userID, fcmToken string) error
+	UpdatePasswordHash(ctx context.Context, userID, passwordHash string) error
+	SetRecoveryCodes(ctx context.Context, userID string, codeHashes []string) error
+	ResetPasswordWithRecoveryCode(ctx context.Context, userID, codeHash, passwordHash string) (bool, error)
 }
 
 func NewAuthService() *AuthService {
@@ -24,25 +40,27 @@ func NewAuthService() *AuthService {
 }
 
 // Register creates a new user account
-func (s *AuthService) Register(ctx context.Context, req *models.RegisterRequest) (*models.AuthResponse, error) {
+func (s *AuthService) Register(ctx context.Context, req *models.RegisterRequest, clientIP string) (*models.AuthResponse, error) {
+	// Every registration attempt counts towards the per-IP limit
+	lockout, err := getAuthLimiters().registerIP.Record(ctx, clientIP)
+	if err != nil {
+		slog.WarnContext(ctx, "registration limiter unavailable", "error", err)
+	} else if lockout > 0 {
+		return nil, fmt.Errorf("too_many_attempts:%d", retryAfterSeconds(lockout))
+	}
+
 	// Validate username
 	if err := utils.ValidateUsername(req.Username); err != nil {
 		return nil, err
 	}
 
 	// Validate password
-	if err := utils.ValidatePassword(req.Password); err != nil {
+	if err := utils.ValidatePasswordForUser(req.Password, req.Username); err != nil {
 		return nil, err
 	}
 
-	// Check if username already exists
-	existingUser, _ := s.userRepo.GetUserByUsername(ctx, req.Username)
-	if existingUser != nil {
-		return nil, errors.New("username already taken")
-	}
-
 	// Hash password
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	hashedPassword, err := hashPassword(req.Password)
 	if err != nil {
 		return nil, err
 	}
@@ -50,19 +68,29 @@ func (s *AuthService) Register(ctx context.Context, req *models.RegisterRequest)
 	// Generate user ID
 	userID := generateUserID()
 
+	// One-time recovery codes are the only way back into an account (we have no email)
+	recoveryCodes, recoveryCodeHashes := generateRecoveryCodes()
+
 	// Create user
 	user := &models.User{
-		UserID:       userID,
-		Username:     req.Username,
-		PasswordHash: string(hashedPassword),
-		CreatedAt:    time.Now(),
-		MutedAll:     false,
+		UserID:             userID,
+		Username:           req.Username,
+		PasswordHash:       hashedPassword,
+		RecoveryCodeHashes: recoveryCodeHashes,
+		CreatedAt:          time.Now(),
+		MutedAll:           false,
 	}
 
+	// Reserves the username atomically; fails with "username already taken" on any case-insensitive clash
 	if err := s.userRepo.CreateUser(ctx, user); err != nil {
 		return nil, err
 	}
 
+	// Make the new user searchable right away
+	search.GetIndex().Upsert(userID, user.Username)
+
+	audit.UserAction(ctx, userID, models.AuditRegister, "", nil, nil)
+
 	// Generate token (simple random token for now, can be JWT later)
 	token := generateToken()
 
@@ -70,23 +98,73 @@ func (s *AuthService) Register(ctx context.Context, req *models.RegisterRequest)
 	GetTokenStore().StoreToken(token, userID)
 
 	return &models.AuthResponse{
-		UserID:   userID,
-		Username: req.Username,
-		Token:    token,
+		UserID:        userID,
+		Username:      req.Username,
+		Token:         token,
+		RecoveryCodes: recoveryCodes,
 	}, nil
 }
 
-// Login authenticates a user
-func (s *AuthService) Login(ctx context.Context, req *models.LoginRequest) (*models.AuthResponse, error) {
+// Login authenticates a user.
+// Failed attempts are tracked per IP and per username; too many lock the key out with exponential backoff.
+func (s *AuthService) Login(ctx context.Context, req *models.LoginRequest, clientIP string) (*models.AuthResponse, error) {
+	limiters := getAuthLimiters()
+	userKey := usernameKey(req.Username)
+
+	// Refuse early (before any bcrypt work) while a lockout is active
+	if lockout, err := limiters.loginIP.Check(ctx, clientIP); err == nil && lockout > 0 {
+		return nil, fmt.Errorf("too_many_attempts:%d", retryAfterSeconds(lockout))
+	}
+	if lockout, err := limiters.loginUser.Check(ctx, userKey); err == nil && lockout > 0 {
+		return nil, fmt.Errorf("account_locked:%d", retryAfterSeconds(lockout))
+	}
+
 	// Get user by username
 	user, err := s.userRepo.GetUserByUsername(ctx, req.Username)
 	if err != nil {
-		return nil, errors.New("invalid username or password")
+		return nil, s.loginFailed(ctx, clientIP, userKey)
 	}
 
 	// Compare password
 	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password)); err != nil {
-		return nil, errors.New("invalid username or password")
+		// Only attempts against a real account are audited; they show up in its owner's activity
+		audit.Record(ctx, &models.AuditEntry{
+			ActorID:      models.AuditActorAnonymous,
+			Action:       models.AuditLoginFailed,
+			TargetUserID: user.UserID,
+			VisibleTo:    []string{user.UserID},
+		})
+		return nil, s.loginFailed(ctx, clientIP, userKey)
+	}
+
+	if err := limiters.loginUser.Reset(ctx, userKey); err != nil {
+		slog.WarnContext(ctx, "failed to reset login attempts", "error", err)
+	}
+
+	// Transparently upgrade hashes made with an older, cheaper cost (best effort)
+	if needsRehash(user.PasswordHash) {
+		if hash, err := hashPassword(req.Password); err == nil {
+			if err := s.userRepo.UpdatePasswordHash(ctx, user.UserID, hash); err != nil {
+				slog.WarnContext(ctx, "failed to upgrade password hash", "user_id", user.UserID, "error", err)
+			}
+		}
+	}
+
+	// Suspended and banned users can't sign in (checked after the password so status isn't leaked)
+	if restriction := user.Restriction(time.Now()); restriction != nil {
+		return nil, restriction
+	}
+
+	// Logging in during the grace period cancels a pending account deletion
+	deletionCancelled := false
+	if user.DeletionScheduledFor != nil {
+		if err := NewAccountDeletionService().CancelDeletion(ctx, user); err != nil {
+			if err.Error() == "deletion already in progress" {
+				return nil, errors.New("account is being deleted")
+			}
+			return nil, err
+		}
+		deletionCancelled = true
 	}
 
 	// Generate token
@@ -95,19 +173,171 @@ func (s *AuthService) Login(ctx context.Context, req *models.LoginRequest) (*mod
 	// Store token in token store
 	GetTokenStore().StoreToken(token, user.UserID)
 
+	audit.UserAction(ctx, user.UserID, models.AuditLogin, "", nil, nil)
+
 	return &models.AuthResponse{
-		UserID:   user.UserID,
-		Username: user.Username,
-		Token:    token,
+		UserID:            user.UserID,
+		Username:          user.Username,
+		Token:             token,
+		DeletionCancelled: deletionCancelled,
 	}, nil
 }
 
+// loginFailed records a failed login and returns the error to show the client
+func (s *AuthService) loginFailed(ctx context.Context, clientIP, userKey string) error {
+	limiters := getAuthLimiters()
+
+	userLockout, err := limiters.loginUser.Record(ctx, userKey)
+	if err != nil {
+		slog.WarnContext(ctx, "login limiter unavailable", "limiter", "user", "error", err)
+	}
+	ipLockout, err := limiters.loginIP.Record(ctx, clientIP)
+	if err != nil {
+		slog.WarnContext(ctx, "login limiter unavailable", "limiter", "ip", "error", err)
+	}
+
+	// The attempt that crosses the threshold tells the client the account is now locked
+	if userLockout > 0 {
+		return fmt.Errorf("account_locked:%d", retryAfterSeconds(userLockout))
+	}
+	if ipLockout > 0 {
+		return fmt.Errorf("too_many_attempts:%d", retryAfterSeconds(ipLockout))
+	}
+	return errors.New("invalid username or password")
+}
+
 // UpdateFCMToken updates the user's FCM token
 func (s *AuthService) UpdateFCMToken(ctx context.Context, userID, fcmToken string) error {
 	if fcmToken == "" {
 		return errors.New("fcm token cannot be empty")
 	}
-	return s.userRepo.UpdateFCMToken(ctx, userID, fcmToken)
+	if err := s.userRepo.UpdateFCMToken(ctx, userID, fcmToken); err != nil {
+		return err
+	}
+
+	// The token itself is a credential for push delivery, so it's never written to the log
+	audit.UserAction(ctx, userID, models.AuditFCMTokenUpdate, "", nil, nil)
+	return nil
+}
+
+// RefreshToken extends the current session's token
+func (s *AuthService) RefreshToken(ctx context.Context, userID, token string) error {
+	if token == "" || !GetTokenStore().RefreshToken(token) {
+		return errors.New("invalid or expired token")
+	}
+
+	audit.UserAction(ctx, userID, models.AuditTokenRefresh, "", nil, nil)
+	return nil
+}
+
+// ChangePassword changes the user's password and signs out every other session
+func (s *AuthService) ChangePassword(ctx context.Context, userID, currentToken string, req *models.ChangePasswordRequest) error {
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return errors.New("user not found")
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.CurrentPassword)); err != nil {
+		return errors.New("current password is incorrect")
+	}
+
+	if err := utils.ValidatePasswordForUser(req.NewPassword, user.Username); err != nil {
+		return err
+	}
+
+	hash, err := hashPassword(req.NewPassword)
+	if err != nil {
+		return err
+	}
+	if err := s.userRepo.UpdatePasswordHash(ctx, userID, hash); err != nil {
+		return err
+	}
+
+	revoked := GetTokenStore().RevokeUserTokens(userID, currentToken)
+	audit.UserAction(ctx, userID, models.AuditPasswordChange, "", nil, map[string]interface{}{"sessionsRevoked": revoked})
+	return nil
+}
+
+// ResetPassword sets a new password using a one-time recovery code and signs out every session.
+// Wrong codes are tracked per username and lock resets out like failed logins.
+func (s *AuthService) ResetPassword(ctx context.Context, req *models.ResetPasswordRequest) error {
+	limiter := getAuthLimiters().resetUser
+	userKey := usernameKey(req.Username)
+
+	// Refuse early (before any bcrypt work) while a lockout is active
+	if lockout, err := limiter.Check(ctx, userKey); err == nil && lockout > 0 {
+		return fmt.Errorf("too_many_attempts:%d", retryAfterSeconds(lockout))
+	}
+
+	user, err := s.userRepo.GetUserByUsername(ctx, req.Username)
+	if err != nil {
+		return s.resetFailed(ctx, userKey)
+	}
+
+	// Check the code before hashing the new password, so wrong guesses stay cheap
+	codeHash := hashRecoveryCode(req.RecoveryCode)
+	if !slices.Contains(user.RecoveryCodeHashes, codeHash) {
+		return s.resetFailed(ctx, userKey)
+	}
+
+	if err := utils.ValidatePasswordForUser(req.NewPassword, user.Username); err != nil {
+		return err
+	}
+
+	hash, err := hashPassword(req.NewPassword)
+	if err != nil {
+		return err
+	}
+
+	// The code is consumed in a transaction, so a concurrent reset with the same code still fails here
+	consumed, err := s.userRepo.ResetPasswordWithRecoveryCode(ctx, user.UserID, codeHash, hash)
+	if err != nil {
+		return err
+	}
+	if !consumed {
+		return s.resetFailed(ctx, userKey)
+	}
+
+	if err := limiter.Reset(ctx, userKey); err != nil {
+		slog.WarnContext(ctx, "failed to reset recovery attempts", "error", err)
+	}
+
+	revoked := GetTokenStore().RevokeUserTokens(user.UserID, "")
+	audit.UserAction(ctx, user.UserID, models.AuditPasswordReset, "", nil, map[string]interface{}{"sessionsRevoked": revoked})
+	return nil
+}
+
+// resetFailed records a failed recovery-code reset and returns the error to show the client
+func (s *AuthService) resetFailed(ctx context.Context, userKey string) error {
+	lockout, err := getAuthLimiters().resetUser.Record(ctx, userKey)
+	if err != nil {
+		slog.WarnContext(ctx, "reset limiter unavailable", "error", err)
+	}
+	if lockout > 0 {
+		return fmt.Errorf("too_many_attempts:%d", retryAfterSeconds(lockout))
+	}
+	return errors.New("invalid username or recovery code")
+}
+
+// RegenerateRecoveryCodes replaces the user's recovery codes after confirming their password
+func (s *AuthService) RegenerateRecoveryCodes(ctx context.Context, userID, password string) ([]string, error) {
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
+		return nil, errors.New("current password is incorrect")
+	}
+
+	codes, hashes := generateRecoveryCodes()
+	if err := s.userRepo.SetRecoveryCodes(ctx, userID, hashes); err != nil {
+		return nil, err
+	}
+
+	audit.UserAction(ctx, userID, models.AuditRecoveryCodesRegenerate, "", nil, nil)
+
+	return codes, nil
 }
 
 // Logout invalidates a user's token
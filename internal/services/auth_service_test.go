@@ -0,0 +1,197 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/yourusername/rbd-service/internal/models"
+	"github.com/yourusername/rbd-service/internal/testutil"
+)
+
+// Runs against the Firestore emulator; skipped when FIRESTORE_EMULATOR_HOST is unset
+func TestRegisterSameUsernameConcurrently(t *testing.T) {
+	client := testutil.Firestore(t)
+	ctx := context.Background()
+	service := NewAuthService()
+
+	// Same name, different case: the reservation is on the lowercased name, so only one may win
+	name := strings.ReplaceAll(testutil.ID("r"), "-", "_")
+	usernames := []string{name, strings.ToUpper(name)}
+
+	results := make([]*models.AuthResponse, len(usernames))
+	errs := make([]error, len(usernames))
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	for i, username := range usernames {
+		wg.Add(1)
+		go func(i int, username string) {
+			defer wg.Done()
+			<-start
+			results[i], errs[i] = service.Register(ctx, &models.RegisterRequest{
+				Username: username,
+				Password: "Correct-Horse-Battery-9",
+			}, fmt.Sprintf("203.0.113.%d", i+1))
+		}(i, username)
+	}
+	close(start)
+	wg.Wait()
+
+	succeeded := 0
+	var winner *models.AuthResponse
+	for i, err := range errs {
+		switch {
+		case err == nil:
+			succeeded++
+			winner = results[i]
+		case err.Error() != "username already taken":
+			t.Fatalf("register %q: unexpected error %v", usernames[i], err)
+		}
+	}
+	if succeeded != 1 {
+		t.Fatalf("expected exactly one registration to succeed, got %d (errors: %v)", succeeded, errs)
+	}
+
+	// The reservation and the user document must agree on who won
+	reservation, err := client.Collection("usernames").Doc(strings.ToLower(name)).Get(ctx)
+	if err != nil {
+		t.Fatalf("reservation missing: %v", err)
+	}
+	if owner, _ := reservation.DataAt("userId"); owner != winner.UserID {
+		t.Fatalf("reservation held by %v, expected %s", owner, winner.UserID)
+	}
+
+	users, err := client.Collection("users").Where("usernameLower", "==", strings.ToLower(name)).Documents(ctx).GetAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(users) != 1 {
+		t.Fatalf("expected one user document for %q, got %d", name, len(users))
+	}
+}
+
+// fakeUserStore applies the same reservation rule as the repository's CreateUser transaction,
+// with a mutex standing in for the transaction
+type fakeUserStore struct {
+	authUserStore // methods Register doesn't use panic if called
+
+	mu           sync.Mutex
+	users        map[string]*models.User
+	reservations map[string]*models.UsernameReservation
+}
+
+func newFakeUserStore() *fakeUserStore {
+	return &fakeUserStore{
+		users:        map[string]*models.User{},
+		reservations: map[string]*models.UsernameReservation{},
+	}
+}
+
+func (f *fakeUserStore) CreateUser(ctx context.Context, user *models.User) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	user.UsernameLower = strings.ToLower(user.Username)
+	if reservation, ok := f.reservations[user.UsernameLower]; ok && !reservation.AvailableTo(user.UserID, time.Now()) {
+		return errors.New("username already taken")
+	}
+
+	f.reservations[user.UsernameLower] = &models.UsernameReservation{
+		UsernameLower: user.UsernameLower,
+		UserID:        user.UserID,
+		ReservedAt:    time.Now(),
+	}
+	f.users[user.UserID] = user
+	return nil
+}
+
+func TestRegisterRacingCaseVariants(t *testing.T) {
+	store := newFakeUserStore()
+	service := &AuthService{userRepo: store}
+	ctx := context.Background()
+
+	usernames := []string{"racer_one", "RACER_ONE", "Racer_One", "rAcEr_OnE", "racer_ONE", "RACER_one"}
+	errs := make([]error, len(usernames))
+	results := make([]*models.AuthResponse, len(usernames))
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	for i, username := range usernames {
+		wg.Add(1)
+		go func(i int, username string) {
+			defer wg.Done()
+			<-start
+			results[i], errs[i] = service.Register(ctx, &models.RegisterRequest{
+				Username: username,
+				Password: "Correct-Horse-Battery-9",
+			}, fmt.Sprintf("198.51.100.%d", i+1))
+		}(i, username)
+	}
+	close(start)
+	wg.Wait()
+
+	var winner *models.AuthResponse
+	for i, err := range errs {
+		switch {
+		case err == nil:
+			if winner != nil {
+				t.Fatalf("both %q and %q registered", winner.Username, usernames[i])
+			}
+			winner = results[i]
+		case err.Error() != "username already taken":
+			t.Fatalf("register %q: unexpected error %v", usernames[i], err)
+		}
+	}
+	if winner == nil {
+		t.Fatal("no registration succeeded")
+	}
+
+	if owner := store.reservations["racer_one"].UserID; owner != winner.UserID {
+		t.Fatalf("reservation held by %s, expected the winner %s", owner, winner.UserID)
+	}
+	if len(store.users) != 1 {
+		t.Fatalf("expected one user to be created, got %d", len(store.users))
+	}
+}
+
+func TestRegisterHeldUsername(t *testing.T) {
+	past := time.Now().Add(-time.Minute)
+	future := time.Now().Add(time.Hour)
+
+	tests := []struct {
+		name      string
+		heldUntil *time.Time
+		taken     bool
+	}{
+		{"owned by another user", nil, true},
+		{"held after a rename", &future, true},
+		{"hold has expired", &past, false},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newFakeUserStore()
+			store.reservations["held_name"] = &models.UsernameReservation{
+				UsernameLower: "held_name",
+				UserID:        "previous-owner",
+				HeldUntil:     tt.heldUntil,
+			}
+			service := &AuthService{userRepo: store}
+
+			_, err := service.Register(context.Background(), &models.RegisterRequest{
+				Username: "Held_Name",
+				Password: "Correct-Horse-Battery-9",
+			}, fmt.Sprintf("198.51.100.%d", 100+i))
+
+			if tt.taken && (err == nil || err.Error() != "username already taken") {
+				t.Fatalf("expected \"username already taken\", got %v", err)
+			}
+			if !tt.taken && err != nil {
+				t.Fatalf("expected the expired hold to be claimable, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,299 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/yourusername/rbd-service/internal/audit"
+	"github.com/yourusername/rbd-service/internal/health"
+	"github.com/yourusername/rbd-service/internal/metrics"
+	"github.com/yourusername/rbd-service/internal/models"
+	"github.com/yourusername/rbd-service/internal/repository"
+	"github.com/yourusername/rbd-service/internal/search"
+	"github.com/yourusername/rbd-service/internal/storage"
+	"github.com/yourusername/rbd-service/pkg/utils"
+	"golang.org/x/crypto/bcrypt"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// defaultDeletionGracePeriod is how long a deletion can be cancelled by logging in
+	defaultDeletionGracePeriod = 7 * 24 * time.Hour
+	// deletionLease is how long a worker owns a job before another instance may resume it
+	deletionLease = 10 * time.Minute
+	// deletionWorkerInterval is how often the worker looks for due jobs
+	deletionWorkerInterval = 5 * time.Minute
+	// deletionRetryBase is the wait after a job's first failure; it doubles with every further attempt
+	deletionRetryBase = 5 * time.Minute
+	// deletionRetryMax caps the wait between retries
+	deletionRetryMax = 6 * time.Hour
+	// deletionMaxAttempts is how many times a job is tried before it's parked for an operator
+	deletionMaxAttempts = 10
+)
+
+// deletionRetryDelay returns how long to wait before retrying a job that has failed attempts times
+func deletionRetryDelay(attempts int) time.Duration {
+	delay := deletionRetryBase
+	for i := 1; i < attempts && delay < deletionRetryMax; i++ {
+		delay *= 2
+	}
+	return min(delay, deletionRetryMax)
+}
+
+// deletionGracePeriod returns ACCOUNT_DELETION_GRACE_PERIOD (e.g. "168h") or the default
+func deletionGracePeriod() time.Duration {
+	if d, err := time.ParseDuration(os.Getenv("ACCOUNT_DELETION_GRACE_PERIOD")); err == nil && d >= 0 {
+		return d
+	}
+	return defaultDeletionGracePeriod
+}
+
+type AccountDeletionService struct {
+	userRepo     *repository.UserRepository
+	deletionRepo *repository.DeletionRepository
+	friendRepo   *repository.FriendRepository
+	cooldownRepo *repository.CooldownRepository
+	historyRepo  *repository.HistoryRepository
+	statsRepo    *repository.StatsRepository
+	exportRepo   *repository.ExportRepository
+	reportRepo   *repository.ReportRepository
+	auditRepo    *repository.AuditRepository
+	blobStore    storage.BlobStore
+	exportStore  storage.BlobStore
+}
+
+func NewAccountDeletionService() *AccountDeletionService {
+	return &AccountDeletionService{
+		userRepo:     repository.NewUserRepository(),
+		deletionRepo: repository.NewDeletionRepository(),
+		friendRepo:   repository.NewFriendRepository(),
+		cooldownRepo: repository.NewCooldownRepository(),
+		historyRepo:  repository.NewHistoryRepository(),
+		statsRepo:    repository.NewStatsRepository(),
+		exportRepo:   repository.NewExportRepository(),
+		reportRepo:   repository.NewReportRepository(),
+		auditRepo:    repository.NewAuditRepository(),
+		blobStore:    storage.GetBlobStore(),
+		exportStore:  storage.GetExportStore(),
+	}
+}
+
+// RequestDeletion schedules the user's account for deletion after the grace period and signs them out everywhere.
+// The user disappears from search immediately; logging in again before the grace period ends cancels the deletion.
+func (s *AccountDeletionService) RequestDeletion(ctx context.Context, userID, password string) (*models.DeletionJob, error) {
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
+		return nil, errors.New("password is incorrect")
+	}
+
+	job, err := s.deletionRepo.ScheduleDeletion(ctx, userID, time.Now().Add(deletionGracePeriod()))
+	if err != nil {
+		return nil, err
+	}
+
+	revoked := GetTokenStore().RevokeUserTokens(userID, "")
+
+	audit.UserAction(ctx, userID, models.AuditDeletionRequested, "", nil, map[string]interface{}{
+		"scheduledFor":    job.ScheduledFor,
+		"sessionsRevoked": revoked,
+	})
+
+	slog.InfoContext(ctx, "account scheduled for deletion", "user_id", userID, "scheduled_for", job.ScheduledFor)
+	return job, nil
+}
+
+// CancelDeletion cancels a pending deletion (called when the user logs in during the grace period)
+func (s *AccountDeletionService) CancelDeletion(ctx context.Context, user *models.User) error {
+	if err := s.deletionRepo.CancelDeletion(ctx, user.UserID); err != nil {
+		return err
+	}
+
+	audit.UserAction(ctx, user.UserID, models.AuditDeletionCancelled, "", nil, nil)
+
+	slog.InfoContext(ctx, "account deletion cancelled", "user_id", user.UserID)
+	return nil
+}
+
+// deletionStep is one idempotent part of the cascade, returning how many documents it deleted
+type deletionStep struct {
+	name string
+	run  func(ctx context.Context, user *models.User) (int, error)
+}
+
+// steps returns the cascade in order. The user document goes last so a half-finished job can always be resumed.
+func (s *AccountDeletionService) steps() []deletionStep {
+	return []deletionStep{
+		{"sessions", func(ctx context.Context, user *models.User) (int, error) {
+			return GetTokenStore().RevokeUserTokens(user.UserID, ""), nil
+		}},
+		{"search", func(ctx context.Context, user *models.User) (int, error) {
+			search.GetIndex().Remove(user.UserID)
+			return 0, nil
+		}},
+		{"friendships", func(ctx context.Context, user *models.User) (int, error) {
+			return s.friendRepo.DeleteFriendshipsForUser(ctx, user.UserID)
+		}},
+		{"cooldowns", func(ctx context.Context, user *models.User) (int, error) {
+			return s.cooldownRepo.DeleteCooldownsForUser(ctx, user.UserID)
+		}},
+		{"history", func(ctx context.Context, user *models.User) (int, error) {
+			return s.historyRepo.DeleteHistoryForUser(ctx, user.UserID)
+		}},
+		{"stats", func(ctx context.Context, user *models.User) (int, error) {
+			return s.statsRepo.DeleteStatsForUser(ctx, user.UserID)
+		}},
+		{"avatar", func(ctx context.Context, user *models.User) (int, error) {
+			if user.AvatarKey == "" {
+				return 0, nil
+			}
+			for _, size := range utils.AvatarSizes {
+				if err := s.blobStore.Delete(ctx, avatarBlobKey(user.AvatarKey, size)); err != nil {
+					return 0, err
+				}
+			}
+			return len(utils.AvatarSizes), nil
+		}},
+		{"exports", func(ctx context.Context, user *models.User) (int, error) {
+			exports, err := s.exportRepo.GetExportsForUser(ctx, user.UserID)
+			if err != nil {
+				return 0, err
+			}
+			for _, export := range exports {
+				if export.BlobKey == "" {
+					continue
+				}
+				if err := s.exportStore.Delete(ctx, export.BlobKey); err != nil {
+					return 0, err
+				}
+			}
+			return s.exportRepo.DeleteExportsForUser(ctx, user.UserID)
+		}},
+		{"reports", func(ctx context.Context, user *models.User) (int, error) {
+			// Reports about the user go with the account; ones they filed or handled are kept, anonymized
+			deleted, err := s.reportRepo.DeleteReportsAbout(ctx, user.UserID)
+			if err != nil {
+				return deleted, err
+			}
+			anonymized, err := s.reportRepo.AnonymizeReportsBy(ctx, user.UserID)
+			return deleted + anonymized, err
+		}},
+		{"audit", func(ctx context.Context, user *models.User) (int, error) {
+			return s.auditRepo.AnonymizeUser(ctx, user.UserID)
+		}},
+		{"usernames", func(ctx context.Context, user *models.User) (int, error) {
+			return s.userRepo.ReleaseUsernames(ctx, user.UserID)
+		}},
+		{"user", func(ctx context.Context, user *models.User) (int, error) {
+			return s.userRepo.DeleteUser(ctx, user.UserID)
+		}},
+	}
+}
+
+// ProcessDueDeletions runs every deletion job that is due, resuming any that were interrupted
+func (s *AccountDeletionService) ProcessDueDeletions(ctx context.Context) error {
+	userIDs, err := s.deletionRepo.GetClaimableJobIDs(ctx, 50)
+	if err != nil {
+		return err
+	}
+
+	for _, userID := range userIDs {
+		job, err := s.deletionRepo.ClaimJob(ctx, userID, deletionLease)
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to claim deletion job", "user_id", userID, "error", err)
+			continue
+		}
+		if job == nil {
+			continue // Cancelled, not due yet, or another instance has it
+		}
+
+		if err := s.runJob(ctx, job); err != nil {
+			s.failJob(ctx, job, err)
+			continue
+		}
+		slog.InfoContext(ctx, "account deleted", "user_id", userID)
+	}
+
+	parked, err := s.deletionRepo.CountParked(ctx)
+	if err != nil {
+		return err
+	}
+	metrics.DeletionJobsParked.Set(float64(parked))
+	return nil
+}
+
+// failJob schedules a retry with exponential backoff, or parks the job once it has used up its attempts
+func (s *AccountDeletionService) failJob(ctx context.Context, job *models.DeletionJob, cause error) {
+	if job.Attempts >= deletionMaxAttempts {
+		slog.ErrorContext(ctx, "account deletion parked after repeated failures", "user_id", job.UserID, "attempts", job.Attempts, "error", cause)
+		if err := s.deletionRepo.ParkJob(ctx, job.UserID, cause); err != nil {
+			slog.ErrorContext(ctx, "failed to park deletion job", "user_id", job.UserID, "error", err)
+		}
+		return
+	}
+
+	retryAt := time.Now().Add(deletionRetryDelay(job.Attempts))
+	slog.ErrorContext(ctx, "account deletion failed", "user_id", job.UserID, "attempt", job.Attempts, "retry_at", retryAt, "error", cause)
+	if err := s.deletionRepo.FailJob(ctx, job.UserID, cause, retryAt); err != nil {
+		slog.ErrorContext(ctx, "failed to record deletion failure", "user_id", job.UserID, "error", err)
+	}
+}
+
+// runJob runs the remaining steps of a claimed job, recording progress after each one
+func (s *AccountDeletionService) runJob(ctx context.Context, job *models.DeletionJob) error {
+	user, err := s.userRepo.GetUserByID(ctx, job.UserID)
+	if status.Code(err) == codes.NotFound {
+		user = &models.User{UserID: job.UserID}
+	} else if err != nil {
+		return err
+	}
+
+	for _, step := range s.steps() {
+		if job.StepDone(step.name) {
+			continue
+		}
+
+		deleted, err := step.run(ctx, user)
+		if err != nil {
+			return fmt.Errorf("%s: %w", step.name, err)
+		}
+		if err := s.deletionRepo.RecordStep(ctx, job.UserID, step.name, deleted, deletionLease); err != nil {
+			return err
+		}
+	}
+
+	return s.deletionRepo.CompleteJob(ctx, job.UserID)
+}
+
+// RunDeletionWorker processes due deletion jobs periodically until ctx is cancelled
+func RunDeletionWorker(ctx context.Context) {
+	service := NewAccountDeletionService()
+
+	health.WatchWorker("account_deletion", 3*deletionWorkerInterval)
+	ticker := time.NewTicker(deletionWorkerInterval)
+	defer ticker.Stop()
+
+	for {
+		start := time.Now()
+		err := service.ProcessDueDeletions(ctx)
+		metrics.ObserveJob("account_deletion", start, err)
+		health.Beat("account_deletion")
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to process account deletions", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
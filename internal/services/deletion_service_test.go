@@ -0,0 +1,28 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDeletionRetryDelay(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, 5 * time.Minute},
+		{2, 10 * time.Minute},
+		{3, 20 * time.Minute},
+		{6, 160 * time.Minute},
+		{7, 320 * time.Minute},
+		{8, deletionRetryMax},
+		{deletionMaxAttempts, deletionRetryMax},
+		{1000, deletionRetryMax},
+	}
+
+	for _, tt := range tests {
+		if got := deletionRetryDelay(tt.attempts); got != tt.want {
+			t.Errorf("deletionRetryDelay(%d) = %s, expected %s", tt.attempts, got, tt.want)
+		}
+	}
+}
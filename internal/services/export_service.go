@@ -0,0 +1,401 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/yourusername/rbd-service/internal/health"
+	"github.com/yourusername/rbd-service/internal/metrics"
+	"github.com/yourusername/rbd-service/internal/models"
+	"github.com/yourusername/rbd-service/internal/repository"
+	"github.com/yourusername/rbd-service/internal/storage"
+)
+
+const (
+	// exportRetention is how long a finished archive is kept
+	exportRetention = 7 * 24 * time.Hour
+	// exportLinkTTL is how long a download link stays valid
+	exportLinkTTL = 15 * time.Minute
+	// exportStaleAfter is when a pending export is assumed lost (e.g. the instance restarted mid-build)
+	exportStaleAfter = time.Hour
+	// exportSweepInterval is how often expired archives are deleted
+	exportSweepInterval = time.Hour
+	// exportSweepBatch is how many expired archives one sweep deletes at most
+	exportSweepBatch = 200
+)
+
+type ExportService struct {
+	userRepo    *repository.UserRepository
+	friendRepo  *repository.FriendRepository
+	historyRepo *repository.HistoryRepository
+	exportRepo  *repository.ExportRepository
+	store       storage.BlobStore
+}
+
+func NewExportService() *ExportService {
+	return &ExportService{
+		userRepo:    repository.NewUserRepository(),
+		friendRepo:  repository.NewFriendRepository(),
+		historyRepo: repository.NewHistoryRepository(),
+		exportRepo:  repository.NewExportRepository(),
+		store:       storage.GetExportStore(),
+	}
+}
+
+// RequestExport starts building an archive of the user's data in the background.
+// Only one export per user can be in progress at a time.
+func (s *ExportService) RequestExport(ctx context.Context, userID string) (*models.DataExport, error) {
+	exports, err := s.exportRepo.GetExportsForUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, export := range exports {
+		if export.Status == models.ExportPending && time.Since(export.RequestedAt) < exportStaleAfter {
+			return nil, errors.New("export already in progress")
+		}
+	}
+
+	export, err := s.exportRepo.CreateExport(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Detached from the request so the build outlives it; shutdown waits for it in FlushPending
+	pending.Add(1)
+	go func() {
+		defer pending.Done()
+		s.build(context.Background(), export)
+	}()
+
+	return export, nil
+}
+
+// GetExport returns an export's status. Once it's ready, each call issues a fresh short-lived download link.
+func (s *ExportService) GetExport(ctx context.Context, userID, exportID string) (*models.DataExportResponse, error) {
+	export, err := s.exportRepo.GetExport(ctx, exportID)
+	if err != nil || export.UserID != userID {
+		return nil, errors.New("export not found")
+	}
+
+	now := time.Now()
+	switch {
+	case export.Status == models.ExportPending && now.Sub(export.RequestedAt) > exportStaleAfter:
+		export.Status = models.ExportFailed
+		export.Error = "export timed out"
+		if err := s.exportRepo.MarkFailed(ctx, export.ExportID, errors.New(export.Error)); err != nil {
+			return nil, err
+		}
+	case export.Status == models.ExportReady && export.ExpiresAt != nil && now.After(*export.ExpiresAt):
+		// The sweeper hasn't got to it yet
+		if err := s.expire(ctx, export); err != nil {
+			return nil, err
+		}
+	}
+
+	response := &models.DataExportResponse{DataExport: export}
+	if export.Status != models.ExportReady {
+		return response, nil
+	}
+
+	token := generateToken()
+	linkExpiresAt := now.Add(exportLinkTTL)
+	if err := s.exportRepo.SetDownloadToken(ctx, export.ExportID, hashExportToken(token), linkExpiresAt); err != nil {
+		return nil, err
+	}
+	response.DownloadURL = fmt.Sprintf("%s/api/exports/%s/download?token=%s",
+		os.Getenv("PUBLIC_BASE_URL"), export.ExportID, url.QueryEscape(token))
+	response.DownloadExpiresAt = &linkExpiresAt
+
+	return response, nil
+}
+
+// Download returns the archive for a valid download link token, with a suggested file name
+func (s *ExportService) Download(ctx context.Context, exportID, token string) ([]byte, string, error) {
+	invalid := errors.New("download link is invalid or expired")
+
+	export, err := s.exportRepo.GetExport(ctx, exportID)
+	if err != nil || export.Status != models.ExportReady || export.DownloadTokenHash == "" {
+		return nil, "", invalid
+	}
+	if export.DownloadExpiresAt == nil || time.Now().After(*export.DownloadExpiresAt) {
+		return nil, "", invalid
+	}
+	if subtle.ConstantTimeCompare([]byte(hashExportToken(token)), []byte(export.DownloadTokenHash)) != 1 {
+		return nil, "", invalid
+	}
+
+	data, err := s.store.Get(ctx, export.BlobKey)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return data, fmt.Sprintf("rbd-export-%s.zip", export.RequestedAt.UTC().Format("2006-01-02")), nil
+}
+
+// expire deletes an export's archive and marks the export expired
+func (s *ExportService) expire(ctx context.Context, export *models.DataExport) error {
+	if err := s.store.Delete(ctx, export.BlobKey); err != nil {
+		return err
+	}
+	if err := s.exportRepo.MarkExpired(ctx, export.ExportID); err != nil {
+		return err
+	}
+	export.Status = models.ExportExpired
+	return nil
+}
+
+// SweepExpired deletes archives past their retention, whether or not anyone asks for them again.
+// Returns how many were deleted.
+func (s *ExportService) SweepExpired(ctx context.Context) (int, error) {
+	exports, err := s.exportRepo.ListExpired(ctx, time.Now(), exportSweepBatch)
+	if err != nil {
+		return 0, err
+	}
+
+	expired := 0
+	for _, export := range exports {
+		if err := s.expire(ctx, export); err != nil {
+			return expired, err
+		}
+		expired++
+	}
+	return expired, nil
+}
+
+// RunExportSweeper deletes expired export archives once an hour until ctx is cancelled
+func RunExportSweeper(ctx context.Context) {
+	service := NewExportService()
+
+	health.WatchWorker("export_sweep", 3*exportSweepInterval)
+	ticker := time.NewTicker(exportSweepInterval)
+	defer ticker.Stop()
+
+	for {
+		start := time.Now()
+		expired, err := service.SweepExpired(ctx)
+		metrics.ObserveJob("export_sweep", start, err)
+		health.Beat("export_sweep")
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to delete expired exports", "error", err)
+		}
+		if expired > 0 {
+			slog.InfoContext(ctx, "deleted expired export archives", "count", expired)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// build gathers the user's data, writes the archive and records the result
+func (s *ExportService) build(ctx context.Context, export *models.DataExport) {
+	start := time.Now()
+	data, err := s.buildArchive(ctx, export.UserID)
+	if err == nil {
+		key := fmt.Sprintf("exports/%s/%s.zip", export.UserID, export.ExportID)
+		if _, err = s.store.Put(ctx, key, "application/zip", data); err == nil {
+			err = s.exportRepo.MarkReady(ctx, export.ExportID, key, len(data), time.Now().Add(exportRetention))
+		}
+	}
+
+	metrics.ObserveJob("data_export", start, err)
+	if err != nil {
+		slog.ErrorContext(ctx, "data export failed", "export_id", export.ExportID, "user_id", export.UserID, "error", err)
+		if err := s.exportRepo.MarkFailed(ctx, export.ExportID, err); err != nil {
+			slog.ErrorContext(ctx, "failed to record export failure", "export_id", export.ExportID, "error", err)
+		}
+		return
+	}
+
+	slog.InfoContext(ctx, "data export ready", "export_id", export.ExportID, "user_id", export.UserID, "bytes", len(data))
+}
+
+// buildArchive writes the zip archive described in docs/data-export.md
+func (s *ExportService) buildArchive(ctx context.Context, userID string) ([]byte, error) {
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	usernameHistory, err := s.userRepo.GetUsernameHistory(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	friendships, err := s.friendRepo.GetAllFriendships(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	history, err := s.historyRepo.GetAllHistoryForUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	profile := &models.ExportProfile{
+		UserID:               user.UserID,
+		Username:             user.Username,
+		DisplayName:          user.DisplayName,
+		Bio:                  user.Bio,
+		AvatarURL:            user.AvatarURL,
+		CreatedAt:            user.CreatedAt,
+		MutedAll:             user.MutedAll,
+		PushTokenRegistered:  user.FCMToken != "",
+		RecoveryCodesLeft:    len(user.RecoveryCodeHashes),
+		UsernameChangedAt:    user.UsernameChangedAt,
+		UsernameHistory:      usernameHistory,
+		DeletionScheduledFor: user.DeletionScheduledFor,
+	}
+
+	friends := []*models.ExportFriendship{}
+	for otherID, f := range friendships {
+		entry := &models.ExportFriendship{
+			UserID:      otherID,
+			Status:      f.Status,
+			Direction:   "sent",
+			RequestedAt: f.RequestedAt,
+			AcceptedAt:  f.AcceptedAt,
+		}
+		if f.User1ID == userID {
+			entry.Muted = f.User1Muted
+			entry.CooldownMinutes = f.User1CooldownMinutes
+			entry.FriendCooldownMinutes = f.User2CooldownMinutes
+		} else {
+			entry.Direction = "received"
+			entry.Muted = f.User2Muted
+			entry.CooldownMinutes = f.User2CooldownMinutes
+			entry.FriendCooldownMinutes = f.User1CooldownMinutes
+		}
+		if other, err := s.userRepo.GetUserByID(ctx, otherID); err == nil {
+			entry.Username = other.Username
+		}
+		friends = append(friends, entry)
+	}
+
+	entries := make([]*models.ExportHistoryEntry, len(history))
+	for i, h := range history {
+		entries[i] = &models.ExportHistoryEntry{History: h, HiddenByYou: !containsString(h.VisibleTo, userID)}
+	}
+
+	sessions := []*models.ExportSession{}
+	for _, info := range GetTokenStore().ListUserSessions(userID) {
+		sessions = append(sessions, &models.ExportSession{CreatedAt: info.CreatedAt, ExpiresAt: info.ExpiresAt})
+	}
+
+	historyCSV, err := historyToCSV(userID, entries)
+	if err != nil {
+		return nil, err
+	}
+
+	files := []struct {
+		name string
+		data interface{}
+	}{
+		{"profile.json", profile},
+		{"friendships.json", friends},
+		{"history.json", entries},
+		{"sessions.json", sessions},
+	}
+
+	manifest := &models.ExportManifest{
+		FormatVersion: models.ExportFormatVersion,
+		GeneratedAt:   time.Now().UTC(),
+		UserID:        userID,
+	}
+	for _, f := range files {
+		manifest.Files = append(manifest.Files, f.name)
+	}
+	manifest.Files = append(manifest.Files, "history.csv")
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	if err := writeZipJSON(zw, "manifest.json", manifest); err != nil {
+		return nil, err
+	}
+	for _, f := range files {
+		if err := writeZipJSON(zw, f.name, f.data); err != nil {
+			return nil, err
+		}
+	}
+	w, err := zw.Create("history.csv")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(historyCSV); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func writeZipJSON(zw *zip.Writer, name string, v interface{}) error {
+	w, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// historyToCSV flattens history.json into one row per record
+func historyToCSV(userID string, entries []*models.ExportHistoryEntry) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+
+	w.Write([]string{
+		"historyId", "type", "direction", "senderId", "senderUsername", "receiverId", "triggeredAt",
+		"state", "deliveredAt", "openedAt", "respondedAt", "response", "responseTo", "read", "readAt", "hiddenByYou",
+	})
+	for _, e := range entries {
+		direction := "received"
+		if e.SenderID == userID {
+			direction = "sent"
+		}
+		w.Write([]string{
+			e.HistoryID, string(e.Type), direction, e.SenderID, e.SenderUsername, e.ReceiverID, formatCSVTime(&e.TriggeredAt),
+			string(e.State), formatCSVTime(e.DeliveredAt), formatCSVTime(e.OpenedAt), formatCSVTime(e.RespondedAt),
+			string(e.Response), e.ResponseTo, strconv.FormatBool(e.Read), formatCSVTime(e.ReadAt), strconv.FormatBool(e.HiddenByYou),
+		})
+	}
+
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+func formatCSVTime(t *time.Time) string {
+	if t == nil || t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+func hashExportToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
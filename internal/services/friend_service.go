@@ -2,11 +2,21 @@ package services
 
 import (
 	"context"
+	"encoding/base64"
 	"errors"
+	"log/slog"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/yourusername/rbd-service/internal/audit"
 	"github.com/yourusername/rbd-service/internal/models"
 	"github.com/yourusername/rbd-service/internal/repository"
+	"github.com/yourusername/rbd-service/internal/search"
+	"github.com/yourusername/rbd-service/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type FriendService struct {
@@ -26,7 +36,10 @@ func NewFriendService() *FriendService {
 }
 
 // GetFriends returns all accepted friends for a user
-func (s *FriendService) GetFriends(ctx context.Context, userID string) ([]*models.FriendInfo, error) {
+func (s *FriendService) GetFriends(ctx context.Context, userID string) (_ []*models.FriendInfo, err error) {
+	ctx, span := tracer.Start(ctx, "FriendService.GetFriends", trace.WithAttributes(attribute.String("user.id", userID)))
+	defer tracing.End(span, &err)
+
 	friendships, err := s.friendRepo.GetAcceptedFriends(ctx, userID)
 	if err != nil {
 		return nil, err
@@ -85,6 +98,7 @@ func (s *FriendService) GetFriends(ctx context.Context, userID string) ([]*model
 		friends = append(friends, &models.FriendInfo{
 			UserID:            friendUserID,
 			Username:          user.Username,
+			AvatarURL:         user.AvatarURL,
 			IsMuted:           iMutedThem,
 			IsMutedBy:         theyMutedMe,
 			CooldownRemaining: cooldownRemaining,
@@ -93,11 +107,15 @@ func (s *FriendService) GetFriends(ctx context.Context, userID string) ([]*model
 		})
 	}
 
+	span.SetAttributes(attribute.Int("friend.count", len(friends)))
 	return friends, nil
 }
 
 // GetPendingRequests returns pending friend requests for a user
-func (s *FriendService) GetPendingRequests(ctx context.Context, userID string) ([]*models.FriendRequest, error) {
+func (s *FriendService) GetPendingRequests(ctx context.Context, userID string) (_ []*models.FriendRequest, err error) {
+	ctx, span := tracer.Start(ctx, "FriendService.GetPendingRequests", trace.WithAttributes(attribute.String("user.id", userID)))
+	defer tracing.End(span, &err)
+
 	friendships, err := s.friendRepo.GetPendingRequests(ctx, userID)
 	if err != nil {
 		return nil, err
@@ -115,6 +133,7 @@ func (s *FriendService) GetPendingRequests(ctx context.Context, userID string) (
 			RequestID:   friendship.FriendshipID,
 			Username:    user.Username,
 			UserID:      friendship.User1ID,
+			AvatarURL:   user.AvatarURL,
 			RequestedAt: friendship.RequestedAt,
 		})
 	}
@@ -122,40 +141,201 @@ func (s *FriendService) GetPendingRequests(ctx context.Context, userID string) (
 	return requests, nil
 }
 
-// SearchUsers searches for users by username
-func (s *FriendService) SearchUsers(ctx context.Context, currentUserID, searchUsername string) ([]*models.FriendInfo, error) {
-	users, err := s.userRepo.SearchUsersByUsername(ctx, searchUsername, 20)
+// SearchUsers searches for users by username.
+// Uses the in-memory search index (substring and typo tolerant) once it's built,
+// falling back to an indexed prefix query in Firestore until then.
+// Each instance keeps its own index, so first pages also pull prefix matches from Firestore
+// into it; users who registered or renamed on another instance are found before the next rebuild.
+// Each result carries its relationship to the current user.
+func (s *FriendService) SearchUsers(ctx context.Context, currentUserID, searchUsername, cursor string, limit int) (_ *models.SearchUsersResponse, err error) {
+	ctx, span := tracer.Start(ctx, "FriendService.SearchUsers", trace.WithAttributes(attribute.String("user.id", currentUserID)))
+	defer tracing.End(span, &err)
+
+	var users []*models.UserSearchResult
+	var nextCursor string
+
+	if index := search.GetIndex(); index.Ready() {
+		if cursor == "" {
+			s.refreshIndexFromStore(ctx, index, searchUsername, limit)
+		}
+		users, nextCursor, err = searchIndex(index, searchUsername, cursor, limit)
+		if err == nil {
+			users, err = s.searchableOnly(ctx, index, users)
+		}
+	} else {
+		users, nextCursor, err = s.searchPrefix(ctx, searchUsername, cursor, limit)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	friendships, err := s.friendRepo.GetAllFriendships(ctx, currentUserID)
 	if err != nil {
 		return nil, err
 	}
 
-	var results []*models.FriendInfo
+	results := []*models.UserSearchResult{}
 	for _, user := range users {
 		// Don't include current user in results
 		if user.UserID == currentUserID {
 			continue
 		}
+		user.Relationship = relationshipOf(friendships[user.UserID], currentUserID)
+		results = append(results, user)
+	}
+
+	return &models.SearchUsersResponse{
+		Users:      results,
+		NextCursor: nextCursor,
+	}, nil
+}
 
-		// Check if username contains search term (case-insensitive would be better)
-		results = append(results, &models.FriendInfo{
+// searchPrefix runs a case-insensitive prefix query against Firestore, exact match first
+func (s *FriendService) searchPrefix(ctx context.Context, searchUsername, cursor string, limit int) ([]*models.UserSearchResult, string, error) {
+	users, nextCursor, err := s.userRepo.SearchUsersByUsername(ctx, searchUsername, cursor, limit)
+	if err != nil {
+		return nil, "", err
+	}
+
+	now := time.Now()
+	results := make([]*models.UserSearchResult, 0, len(users))
+	for _, user := range users {
+		if !user.Searchable(now) {
+			continue
+		}
+		results = append(results, &models.UserSearchResult{
 			UserID:   user.UserID,
 			Username: user.Username,
-			IsMuted:  false,
 		})
 	}
 
+	// Exact matches first, otherwise keep the alphabetical order from the query
+	search := strings.ToLower(strings.TrimSpace(searchUsername))
+	sort.SliceStable(results, func(i, j int) bool {
+		return strings.ToLower(results[i].Username) == search && strings.ToLower(results[j].Username) != search
+	})
+
+	return results, nextCursor, nil
+}
+
+// refreshIndexFromStore upserts Firestore prefix matches the local index is missing or has under an old name.
+// It's best effort: if Firestore is unavailable the search still runs on the index alone.
+func (s *FriendService) refreshIndexFromStore(ctx context.Context, index search.Index, searchUsername string, limit int) {
+	users, _, err := s.userRepo.SearchUsersByUsername(ctx, searchUsername, "", limit)
+	if err != nil {
+		slog.WarnContext(ctx, "failed to refresh search index from store", "error", err)
+		return
+	}
+	for _, user := range users {
+		if name, ok := index.Username(user.UserID); !ok || name != user.Username {
+			index.Upsert(user.UserID, user.Username)
+		}
+	}
+}
+
+// searchableOnly drops index hits for users that are restricted, scheduled for deletion or gone.
+// The index holds every user regardless of status, so the user store decides at query time.
+func (s *FriendService) searchableOnly(ctx context.Context, index search.Index, hits []*models.UserSearchResult) ([]*models.UserSearchResult, error) {
+	ids := make([]string, 0, len(hits))
+	for _, hit := range hits {
+		ids = append(ids, hit.UserID)
+	}
+	users, err := s.userRepo.GetUsersByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	results := make([]*models.UserSearchResult, 0, len(hits))
+	for _, hit := range hits {
+		user, ok := users[hit.UserID]
+		if !ok {
+			// Deleted on another instance
+			index.Remove(hit.UserID)
+			continue
+		}
+		if user.Username != hit.Username {
+			// Renamed on another instance since the last rebuild
+			index.Upsert(user.UserID, user.Username)
+			hit.Username = user.Username
+		}
+		if user.Searchable(now) {
+			results = append(results, hit)
+		}
+	}
 	return results, nil
 }
 
+// searchIndex pages through ranked matches from the search index.
+// The cursor is an opaque offset into the ranked results.
+func searchIndex(index search.Index, searchUsername, cursor string, limit int) ([]*models.UserSearchResult, string, error) {
+	offset := 0
+	if cursor != "" {
+		b, err := base64.RawURLEncoding.DecodeString(cursor)
+		if err != nil {
+			return nil, "", errors.New("invalid cursor")
+		}
+		offset, err = strconv.Atoi(strings.TrimPrefix(string(b), "offset:"))
+		if err != nil || offset < 0 || !strings.HasPrefix(string(b), "offset:") {
+			return nil, "", errors.New("invalid cursor")
+		}
+	}
+
+	// Fetch one extra match to know whether another page exists
+	matches := index.Search(searchUsername, offset+limit+1)
+	if offset >= len(matches) {
+		return []*models.UserSearchResult{}, "", nil
+	}
+	matches = matches[offset:]
+
+	nextCursor := ""
+	if len(matches) > limit {
+		matches = matches[:limit]
+		nextCursor = base64.RawURLEncoding.EncodeToString([]byte("offset:" + strconv.Itoa(offset+limit)))
+	}
+
+	results := make([]*models.UserSearchResult, 0, len(matches))
+	for _, match := range matches {
+		results = append(results, &models.UserSearchResult{
+			UserID:   match.UserID,
+			Username: match.Username,
+		})
+	}
+
+	return results, nextCursor, nil
+}
+
+// relationshipOf describes a friendship record from the current user's point of view
+func relationshipOf(friendship *models.Friendship, currentUserID string) models.Relationship {
+	if friendship == nil {
+		return models.RelationshipNone
+	}
+
+	switch friendship.Status {
+	case models.StatusAccepted:
+		return models.RelationshipFriend
+	case models.StatusPending:
+		if friendship.User1ID == currentUserID {
+			return models.RelationshipPendingSent
+		}
+		return models.RelationshipPendingReceived
+	default:
+		return models.RelationshipNone
+	}
+}
+
 // SendFriendRequest sends a friend request
-func (s *FriendService) SendFriendRequest(ctx context.Context, senderID, targetUserID string) (string, error) {
+func (s *FriendService) SendFriendRequest(ctx context.Context, senderID, targetUserID string) (_ string, err error) {
+	ctx, span := tracer.Start(ctx, "FriendService.SendFriendRequest", trace.WithAttributes(attribute.String("user.id", senderID)))
+	defer tracing.End(span, &err)
+
 	// Check if users are the same
 	if senderID == targetUserID {
 		return "", errors.New("cannot send friend request to yourself")
 	}
 
 	// Check if target user exists
-	_, err := s.userRepo.GetUserByID(ctx, targetUserID)
+	_, err = s.userRepo.GetUserByID(ctx, targetUserID)
 	if err != nil {
 		return "", errors.New("user not found")
 	}
@@ -185,11 +365,15 @@ func (s *FriendService) SendFriendRequest(ctx context.Context, senderID, targetU
 		return "", err
 	}
 
+	audit.UserAction(ctx, senderID, models.AuditFriendRequest, targetUserID, nil, nil)
 	return requestID, nil
 }
 
 // AcceptFriendRequest accepts a friend request
-func (s *FriendService) AcceptFriendRequest(ctx context.Context, userID, requestID string) error {
+func (s *FriendService) AcceptFriendRequest(ctx context.Context, userID, requestID string) (err error) {
+	ctx, span := tracer.Start(ctx, "FriendService.AcceptFriendRequest", trace.WithAttributes(attribute.String("user.id", userID)))
+	defer tracing.End(span, &err)
+
 	// Get friendship
 	friendship, err := s.friendRepo.GetFriendship(ctx, requestID)
 	if err != nil {
@@ -207,11 +391,19 @@ func (s *FriendService) AcceptFriendRequest(ctx context.Context, userID, request
 	}
 
 	// Accept request
-	return s.friendRepo.AcceptFriendRequest(ctx, requestID)
+	if err := s.friendRepo.AcceptFriendRequest(ctx, requestID); err != nil {
+		return err
+	}
+
+	audit.UserAction(ctx, userID, models.AuditFriendAccept, friendship.User1ID, nil, nil)
+	return nil
 }
 
 // RejectFriendRequest rejects a friend request
-func (s *FriendService) RejectFriendRequest(ctx context.Context, userID, requestID string) error {
+func (s *FriendService) RejectFriendRequest(ctx context.Context, userID, requestID string) (err error) {
+	ctx, span := tracer.Start(ctx, "FriendService.RejectFriendRequest", trace.WithAttributes(attribute.String("user.id", userID)))
+	defer tracing.End(span, &err)
+
 	// Get friendship
 	friendship, err := s.friendRepo.GetFriendship(ctx, requestID)
 	if err != nil {
@@ -229,11 +421,19 @@ func (s *FriendService) RejectFriendRequest(ctx context.Context, userID, request
 	}
 
 	// Reject request
-	return s.friendRepo.RejectFriendRequest(ctx, requestID)
+	if err := s.friendRepo.RejectFriendRequest(ctx, requestID); err != nil {
+		return err
+	}
+
+	audit.UserAction(ctx, userID, models.AuditFriendReject, friendship.User1ID, nil, nil)
+	return nil
 }
 
-// RemoveFriend removes a friendship
-func (s *FriendService) RemoveFriend(ctx context.Context, userID, friendUserID string) error {
+// RemoveFriend removes a friendship, optionally deleting the shared history for both users
+func (s *FriendService) RemoveFriend(ctx context.Context, userID, friendUserID string, purgeHistory bool) (err error) {
+	ctx, span := tracer.Start(ctx, "FriendService.RemoveFriend", trace.WithAttributes(attribute.String("user.id", userID)))
+	defer tracing.End(span, &err)
+
 	// Find friendship
 	existing, err := s.friendRepo.CheckExistingFriendship(ctx, userID, friendUserID)
 	if err != nil {
@@ -244,11 +444,26 @@ func (s *FriendService) RemoveFriend(ctx context.Context, userID, friendUserID s
 	}
 
 	// Delete friendship
-	return s.friendRepo.DeleteFriendship(ctx, existing.FriendshipID)
+	if err := s.friendRepo.DeleteFriendship(ctx, existing.FriendshipID); err != nil {
+		return err
+	}
+
+	audit.UserAction(ctx, userID, models.AuditFriendRemove, friendUserID,
+		map[string]interface{}{"status": existing.Status},
+		map[string]interface{}{"purgeHistory": purgeHistory})
+
+	if purgeHistory {
+		return s.historyRepo.DeleteHistoryBetweenUsers(ctx, userID, friendUserID)
+	}
+
+	return nil
 }
 
 // MuteFriend mutes or unmutes a friend
-func (s *FriendService) MuteFriend(ctx context.Context, userID, friendUserID string, muted bool) error {
+func (s *FriendService) MuteFriend(ctx context.Context, userID, friendUserID string, muted bool) (err error) {
+	ctx, span := tracer.Start(ctx, "FriendService.MuteFriend", trace.WithAttributes(attribute.String("user.id", userID)))
+	defer tracing.End(span, &err)
+
 	// Find friendship
 	existing, err := s.friendRepo.CheckExistingFriendship(ctx, userID, friendUserID)
 	if err != nil {
@@ -260,17 +475,46 @@ func (s *FriendService) MuteFriend(ctx context.Context, userID, friendUserID str
 
 	// Determine if current user is User1 or User2
 	isUser1 := existing.User1ID == userID
+	wasMuted := existing.User2Muted
+	if isUser1 {
+		wasMuted = existing.User1Muted
+	}
+
+	if err := s.friendRepo.UpdateMuteStatus(ctx, existing.FriendshipID, isUser1, muted); err != nil {
+		return err
+	}
 
-	return s.friendRepo.UpdateMuteStatus(ctx, existing.FriendshipID, isUser1, muted)
+	audit.UserAction(ctx, userID, models.AuditFriendMute, friendUserID,
+		map[string]interface{}{"muted": wasMuted},
+		map[string]interface{}{"muted": muted})
+	return nil
 }
 
 // MuteAll mutes or unmutes all friends
-func (s *FriendService) MuteAll(ctx context.Context, userID string, mutedAll bool) error {
-	return s.userRepo.UpdateMuteAll(ctx, userID, mutedAll)
+func (s *FriendService) MuteAll(ctx context.Context, userID string, mutedAll bool) (err error) {
+	ctx, span := tracer.Start(ctx, "FriendService.MuteAll", trace.WithAttributes(attribute.String("user.id", userID)))
+	defer tracing.End(span, &err)
+
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return errors.New("user not found")
+	}
+
+	if err := s.userRepo.UpdateMuteAll(ctx, userID, mutedAll); err != nil {
+		return err
+	}
+
+	audit.UserAction(ctx, userID, models.AuditMuteAll, "",
+		map[string]interface{}{"mutedAll": user.MutedAll},
+		map[string]interface{}{"mutedAll": mutedAll})
+	return nil
 }
 
 // UpdateFriendCooldown updates the cooldown duration for a specific friend
-func (s *FriendService) UpdateFriendCooldown(ctx context.Context, userID, friendUserID string, cooldownMinutes int) error {
+func (s *FriendService) UpdateFriendCooldown(ctx context.Context, userID, friendUserID string, cooldownMinutes int) (err error) {
+	ctx, span := tracer.Start(ctx, "FriendService.UpdateFriendCooldown", trace.WithAttributes(attribute.String("user.id", userID)))
+	defer tracing.End(span, &err)
+
 	// Validate cooldown range (1 to 1440 minutes = 1 day)
 	// Minimum 1 minute to avoid ambiguity with uninitialized state
 	if cooldownMinutes < 1 || cooldownMinutes > 1440 {
@@ -293,6 +537,10 @@ func (s *FriendService) UpdateFriendCooldown(ctx context.Context, userID, friend
 
 	// Determine if current user is User1 or User2
 	isUser1 := existing.User1ID == userID
+	previousMinutes := existing.User2CooldownMinutes
+	if isUser1 {
+		previousMinutes = existing.User1CooldownMinutes
+	}
 
 	// Update the cooldown setting in friendship record
 	// This sets "how often friendUserID can trigger userID"
@@ -304,5 +552,9 @@ func (s *FriendService) UpdateFriendCooldown(ctx context.Context, userID, friend
 	// This updates friendUserID->userID cooldown (friend triggering current user)
 	_, _ = s.cooldownRepo.UpdateActiveCooldown(ctx, friendUserID, userID, cooldownMinutes)
 
+	audit.UserAction(ctx, userID, models.AuditFriendCooldown, friendUserID,
+		map[string]interface{}{"cooldownMinutes": previousMinutes},
+		map[string]interface{}{"cooldownMinutes": cooldownMinutes})
+
 	return nil
 }
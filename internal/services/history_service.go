@@ -0,0 +1,98 @@
+package services
+
+import (
+	"context"
+	"errors"
+
+	"github.com/yourusername/rbd-service/internal/models"
+	"github.com/yourusername/rbd-service/internal/repository"
+)
+
+type HistoryService struct {
+	historyRepo historyStore
+	friendRepo  friendshipLookup
+}
+
+func NewHistoryService() *HistoryService {
+	return &HistoryService{
+		historyRepo: repository.NewHistoryRepository(),
+		friendRepo:  repository.NewFriendRepository(),
+	}
+}
+
+// GetHistory returns a page of history between the user and a friend
+func (s *HistoryService) GetHistory(ctx context.Context, userID, friendUserID, cursor string, limit int) (*models.HistoryResponse, error) {
+	if err := checkFriendAccess(ctx, s.friendRepo, s.historyRepo, userID, friendUserID); err != nil {
+		return nil, err
+	}
+
+	history, nextCursor, err := s.historyRepo.GetHistoryBetweenUsers(ctx, userID, friendUserID, cursor, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	total, err := s.historyRepo.CountHistoryBetweenUsers(ctx, userID, friendUserID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.HistoryResponse{
+		History:    history,
+		NextCursor: nextCursor,
+		Total:      total,
+	}, nil
+}
+
+// DeleteHistory removes history with a friend from the user's side only.
+// When historyID is empty the whole shared history is removed from the user's side.
+func (s *HistoryService) DeleteHistory(ctx context.Context, userID, friendUserID, historyID string) (int, error) {
+	if err := checkFriendAccess(ctx, s.friendRepo, s.historyRepo, userID, friendUserID); err != nil {
+		return 0, err
+	}
+
+	return s.historyRepo.HideHistory(ctx, userID, friendUserID, historyID)
+}
+
+// friendshipLookup and sharedHistoryLookup are the repository methods checkFriendAccess relies on
+type friendshipLookup interface {
+	CheckExistingFriendship(ctx context.Context, user1ID, user2ID string) (*models.Friendship, error)
+}
+
+type sharedHistoryLookup interface {
+	HasSharedHistory(ctx context.Context, user1ID, user2ID string) (bool, error)
+}
+
+// historyStore is the part of the history repository HistoryService uses
+type historyStore interface {
+	sharedHistoryLookup
+	GetHistoryBetweenUsers(ctx context.Context, user1ID, user2ID, cursor string, limit int) ([]*models.History, string, error)
+	CountHistoryBetweenUsers(ctx context.Context, user1ID, user2ID string) (int, error)
+	HideHistory(ctx context.Context, userID, friendUserID, historyID string) (int, error)
+}
+
+// checkFriendAccess allows current friends and former friends that still have shared history
+// to see data about each other
+func checkFriendAccess(ctx context.Context, friendRepo friendshipLookup, historyRepo sharedHistoryLookup, userID, friendUserID string) error {
+	if userID == friendUserID {
+		return errors.New("users are not friends")
+	}
+
+	friendship, err := friendRepo.CheckExistingFriendship(ctx, userID, friendUserID)
+	if err != nil {
+		return err
+	}
+	if friendship != nil && friendship.Status == models.StatusAccepted {
+		return nil
+	}
+
+	// History is only written between accepted friends, so any shared history means they were friends once
+	hasHistory, err := historyRepo.HasSharedHistory(ctx, userID, friendUserID)
+	if err != nil {
+		return err
+	}
+	if !hasHistory {
+		return errors.New("users are not friends")
+	}
+
+	return nil
+}
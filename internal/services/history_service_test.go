@@ -0,0 +1,199 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/yourusername/rbd-service/internal/models"
+)
+
+type fakeFriendships map[string]*models.Friendship
+
+func (f fakeFriendships) CheckExistingFriendship(ctx context.Context, user1ID, user2ID string) (*models.Friendship, error) {
+	return f[models.PairKey(user1ID, user2ID)], nil
+}
+
+type fakeSharedHistory map[string]bool
+
+func (f fakeSharedHistory) HasSharedHistory(ctx context.Context, user1ID, user2ID string) (bool, error) {
+	return f[models.PairKey(user1ID, user2ID)], nil
+}
+
+func TestCheckFriendAccess(t *testing.T) {
+	friendships := fakeFriendships{
+		models.PairKey("alice", "bob"):   {User1ID: "alice", User2ID: "bob", Status: models.StatusAccepted},
+		models.PairKey("alice", "carol"): {User1ID: "alice", User2ID: "carol", Status: models.StatusPending},
+	}
+	history := fakeSharedHistory{
+		models.PairKey("alice", "bob"):  true,
+		models.PairKey("alice", "dave"): true, // former friends: friendship removed, history kept
+	}
+
+	tests := []struct {
+		name         string
+		userID       string
+		friendUserID string
+		allowed      bool
+	}{
+		{"current friend", "alice", "bob", true},
+		{"former friend with shared history", "alice", "dave", true},
+		{"former friend, other side", "dave", "alice", true},
+		{"unrelated user", "erin", "bob", false},
+		{"pending request without history", "alice", "carol", false},
+		{"self", "alice", "alice", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkFriendAccess(context.Background(), friendships, history, tt.userID, tt.friendUserID)
+			if tt.allowed && err != nil {
+				t.Fatalf("expected access, got %v", err)
+			}
+			if !tt.allowed && (err == nil || err.Error() != "users are not friends") {
+				t.Fatalf("expected \"users are not friends\", got %v", err)
+			}
+		})
+	}
+}
+
+type failingSharedHistory struct{}
+
+func (failingSharedHistory) HasSharedHistory(ctx context.Context, user1ID, user2ID string) (bool, error) {
+	return false, errors.New("datastore unavailable")
+}
+
+func TestCheckFriendAccessDeniesOnLookupError(t *testing.T) {
+	err := checkFriendAccess(context.Background(), fakeFriendships{}, failingSharedHistory{}, "erin", "bob")
+	if err == nil {
+		t.Fatal("expected an error when shared history can't be checked")
+	}
+}
+
+// fakeHistoryStore records how HistoryService calls the repository
+type fakeHistoryStore struct {
+	fakeSharedHistory
+	page       []*models.History
+	nextCursor string
+	total      int
+	listErr    error
+
+	listed  []string // cursors GetHistoryBetweenUsers was called with
+	limits  []int
+	counted int
+	hidden  []string // history IDs HideHistory was called with
+}
+
+func (f *fakeHistoryStore) GetHistoryBetweenUsers(ctx context.Context, user1ID, user2ID, cursor string, limit int) ([]*models.History, string, error) {
+	f.listed = append(f.listed, cursor)
+	f.limits = append(f.limits, limit)
+	if f.listErr != nil {
+		return nil, "", f.listErr
+	}
+	return f.page, f.nextCursor, nil
+}
+
+func (f *fakeHistoryStore) CountHistoryBetweenUsers(ctx context.Context, user1ID, user2ID string) (int, error) {
+	f.counted++
+	return f.total, nil
+}
+
+func (f *fakeHistoryStore) HideHistory(ctx context.Context, userID, friendUserID, historyID string) (int, error) {
+	f.hidden = append(f.hidden, historyID)
+	return 1, nil
+}
+
+func TestGetHistory(t *testing.T) {
+	friendships := fakeFriendships{
+		models.PairKey("alice", "bob"): {User1ID: "alice", User2ID: "bob", Status: models.StatusAccepted},
+	}
+	shared := fakeSharedHistory{
+		models.PairKey("alice", "bob"):  true,
+		models.PairKey("alice", "dave"): true,
+	}
+	page := []*models.History{{HistoryID: "h2"}, {HistoryID: "h1"}}
+
+	tests := []struct {
+		name         string
+		userID       string
+		friendUserID string
+		allowed      bool
+	}{
+		{"current friend", "alice", "bob", true},
+		{"former friend", "dave", "alice", true},
+		{"unrelated user", "erin", "alice", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &fakeHistoryStore{fakeSharedHistory: shared, page: page, nextCursor: "next", total: 7}
+			service := &HistoryService{historyRepo: store, friendRepo: friendships}
+
+			resp, err := service.GetHistory(context.Background(), tt.userID, tt.friendUserID, "cur", 2)
+			if !tt.allowed {
+				if err == nil || err.Error() != "users are not friends" {
+					t.Fatalf("expected \"users are not friends\", got %v", err)
+				}
+				if len(store.listed) != 0 || store.counted != 0 {
+					t.Fatal("history was read for a user without access")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("expected access, got %v", err)
+			}
+			if len(store.listed) != 1 || store.listed[0] != "cur" || store.limits[0] != 2 {
+				t.Fatalf("cursor and limit not passed through: cursors %v, limits %v", store.listed, store.limits)
+			}
+			if len(resp.History) != 2 || resp.NextCursor != "next" || resp.Total != 7 {
+				t.Fatalf("unexpected response: %d records, cursor %q, total %d", len(resp.History), resp.NextCursor, resp.Total)
+			}
+		})
+	}
+}
+
+func TestGetHistoryPassesThroughInvalidCursor(t *testing.T) {
+	friendships := fakeFriendships{
+		models.PairKey("alice", "bob"): {User1ID: "alice", User2ID: "bob", Status: models.StatusAccepted},
+	}
+	store := &fakeHistoryStore{listErr: errors.New("invalid cursor")}
+	service := &HistoryService{historyRepo: store, friendRepo: friendships}
+
+	_, err := service.GetHistory(context.Background(), "alice", "bob", "garbage", 20)
+	if err == nil || err.Error() != "invalid cursor" {
+		t.Fatalf("expected \"invalid cursor\", got %v", err)
+	}
+	if store.counted != 0 {
+		t.Fatal("history was counted after the page failed")
+	}
+}
+
+func TestDeleteHistory(t *testing.T) {
+	shared := fakeSharedHistory{models.PairKey("alice", "dave"): true}
+
+	t.Run("former friend", func(t *testing.T) {
+		store := &fakeHistoryStore{fakeSharedHistory: shared}
+		service := &HistoryService{historyRepo: store, friendRepo: fakeFriendships{}}
+
+		if _, err := service.DeleteHistory(context.Background(), "alice", "dave", "h1"); err != nil {
+			t.Fatalf("expected access, got %v", err)
+		}
+		if len(store.hidden) != 1 || store.hidden[0] != "h1" {
+			t.Fatalf("expected h1 to be hidden, got %v", store.hidden)
+		}
+	})
+
+	t.Run("unrelated user", func(t *testing.T) {
+		store := &fakeHistoryStore{fakeSharedHistory: shared}
+		service := &HistoryService{historyRepo: store, friendRepo: fakeFriendships{}}
+
+		_, err := service.DeleteHistory(context.Background(), "erin", "dave", "")
+		if err == nil || err.Error() != "users are not friends" {
+			t.Fatalf("expected \"users are not friends\", got %v", err)
+		}
+		if len(store.hidden) != 0 {
+			t.Fatal("history was hidden for a user without access")
+		}
+	})
+}
@@ -0,0 +1,26 @@
+package services
+
+import (
+	"context"
+	"sync"
+)
+
+// pending tracks goroutines that outlive the request that started them, such as export builds.
+// Work that finishes before its handler returns (like FCM sends) is already drained by the HTTP server.
+var pending sync.WaitGroup
+
+// FlushPending waits for detached work to finish, or returns ctx's error if it doesn't finish in time
+func FlushPending(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		pending.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,266 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/yourusername/rbd-service/internal/models"
+	"github.com/yourusername/rbd-service/internal/repository"
+)
+
+const (
+	// reportWindow is how far back reports count towards the automatic suspension threshold
+	reportWindow = 7 * 24 * time.Hour
+	// defaultReportThreshold is how many distinct reporters within reportWindow trigger an automatic suspension
+	defaultReportThreshold = 5
+	// defaultAutoSuspension is how long an automatic suspension lasts (a moderator reviews the reports meanwhile)
+	defaultAutoSuspension = 24 * time.Hour
+	// reportClaimStaleAfter is when another moderator may take over a claimed report
+	reportClaimStaleAfter = time.Hour
+)
+
+// reportThreshold returns REPORT_AUTO_SUSPEND_THRESHOLD or the default (0 disables automatic suspensions)
+func reportThreshold() int {
+	if v, err := strconv.Atoi(os.Getenv("REPORT_AUTO_SUSPEND_THRESHOLD")); err == nil && v >= 0 {
+		return v
+	}
+	return defaultReportThreshold
+}
+
+// autoSuspension returns REPORT_AUTO_SUSPEND_DURATION (e.g. "24h") or the default
+func autoSuspension() time.Duration {
+	if d, err := time.ParseDuration(os.Getenv("REPORT_AUTO_SUSPEND_DURATION")); err == nil && d > 0 {
+		return d
+	}
+	return defaultAutoSuspension
+}
+
+// ModerationService handles user reports and the moderation queue
+type ModerationService struct {
+	userRepo     *repository.UserRepository
+	historyRepo  *repository.HistoryRepository
+	reportRepo   *repository.ReportRepository
+	adminService *AdminService
+}
+
+func NewModerationService() *ModerationService {
+	return &ModerationService{
+		userRepo:     repository.NewUserRepository(),
+		historyRepo:  repository.NewHistoryRepository(),
+		reportRepo:   repository.NewReportRepository(),
+		adminService: NewAdminService(),
+	}
+}
+
+// ReportUser files a report, snapshotting the reported profile and any referenced history.
+// Referenced history must be between the reporter and the reported user.
+func (s *ModerationService) ReportUser(ctx context.Context, reporterID string, req *models.ReportUserRequest) (*models.ReporterReportView, error) {
+	if req.ReportedUserID == reporterID {
+		return nil, errors.New("cannot report yourself")
+	}
+
+	reported, err := s.userRepo.GetUserByID(ctx, req.ReportedUserID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+
+	pending, err := s.reportRepo.HasPendingReport(ctx, reporterID, reported.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if pending {
+		return nil, errors.New("already reported")
+	}
+
+	snapshot := models.ReportSnapshot{
+		Username:    reported.Username,
+		DisplayName: reported.DisplayName,
+		Bio:         reported.Bio,
+		AvatarURL:   reported.AvatarURL,
+	}
+	pairKey := models.PairKey(reporterID, reported.UserID)
+	for _, historyID := range req.HistoryIDs {
+		history, err := s.historyRepo.GetHistoryByID(ctx, historyID)
+		if err != nil || history.PairKey != pairKey {
+			return nil, errors.New("invalid history reference")
+		}
+		snapshot.History = append(snapshot.History, history)
+	}
+
+	report := &models.Report{
+		ReporterID:     reporterID,
+		ReportedUserID: reported.UserID,
+		Category:       req.Category,
+		Details:        req.Details,
+		HistoryIDs:     req.HistoryIDs,
+		Snapshot:       snapshot,
+	}
+	if err := s.reportRepo.CreateReport(ctx, report); err != nil {
+		return nil, err
+	}
+
+	if err := s.checkThreshold(ctx, reported); err != nil {
+		slog.ErrorContext(ctx, "failed to check report threshold", "user_id", reported.UserID, "error", err)
+	}
+
+	return reporterView(report), nil
+}
+
+// autoSuspendable reports whether reports can get the user suspended automatically
+func autoSuspendable(user *models.User, threshold int, now time.Time) bool {
+	return threshold > 0 && user.EffectiveRole() == models.RoleUser && user.Restriction(now) == nil
+}
+
+// checkThreshold suspends a user automatically once enough different people have reported them recently.
+// Staff accounts and users who are already restricted are left alone.
+func (s *ModerationService) checkThreshold(ctx context.Context, user *models.User) error {
+	threshold := reportThreshold()
+	if !autoSuspendable(user, threshold, time.Now()) {
+		return nil
+	}
+
+	reporters, err := s.reportRepo.CountRecentReporters(ctx, user.UserID, time.Now().Add(-reportWindow))
+	if err != nil {
+		return err
+	}
+	if reporters < threshold {
+		return nil
+	}
+
+	until := time.Now().Add(autoSuspension())
+	reason := "Temporarily restricted after multiple reports, pending review"
+	if err := s.userRepo.SetStatus(ctx, user.UserID, models.AccountSuspended, reason, &until, models.AuditActorSystem); err != nil {
+		return err
+	}
+	revoked := s.adminService.restrict(user.UserID)
+
+	s.adminService.audit(ctx, &models.AuditActor{UserID: models.AuditActorSystem}, models.AuditAutoSuspended, user.UserID, reason, map[string]interface{}{
+		"reporters":       reporters,
+		"until":           until,
+		"sessionsRevoked": revoked,
+	})
+	slog.InfoContext(ctx, "automatically suspended user", "user_id", user.UserID, "reporters", reporters)
+	return nil
+}
+
+// GetMyReports lists the reports a user has filed and what came of them
+func (s *ModerationService) GetMyReports(ctx context.Context, reporterID, cursor string, limit int) ([]*models.ReporterReportView, string, error) {
+	reports, nextCursor, err := s.reportRepo.ListByReporter(ctx, reporterID, cursor, limit)
+	if err != nil {
+		return nil, "", err
+	}
+
+	views := make([]*models.ReporterReportView, len(reports))
+	for i, report := range reports {
+		views[i] = reporterView(report)
+	}
+	return views, nextCursor, nil
+}
+
+// GetQueue lists reports with the given status, oldest first
+func (s *ModerationService) GetQueue(ctx context.Context, status models.ReportStatus, cursor string, limit int) (*models.ReportQueueResponse, error) {
+	switch status {
+	case models.ReportOpen, models.ReportInReview, models.ReportResolved, models.ReportDismissed:
+	default:
+		return nil, errors.New("invalid status")
+	}
+
+	reports, nextCursor, err := s.reportRepo.ListByStatus(ctx, status, cursor, limit)
+	if err != nil {
+		return nil, err
+	}
+	return &models.ReportQueueResponse{Reports: reports, NextCursor: nextCursor}, nil
+}
+
+// GetReport returns a report with its snapshot
+func (s *ModerationService) GetReport(ctx context.Context, reportID string) (*models.Report, error) {
+	return s.reportRepo.GetReport(ctx, reportID)
+}
+
+// ClaimReport assigns a report to the moderator so two people don't work on it at once
+func (s *ModerationService) ClaimReport(ctx context.Context, actor *models.AuditActor, reportID string) (*models.Report, error) {
+	report, err := s.reportRepo.ClaimReport(ctx, reportID, actor.UserID, reportClaimStaleAfter)
+	if err != nil {
+		return nil, err
+	}
+
+	s.adminService.audit(ctx, actor, models.AuditReportClaim, report.ReportedUserID, "", map[string]interface{}{
+		"reportId": reportID,
+	})
+	return report, nil
+}
+
+// ResolveReport applies the moderator's decision and closes the report.
+// Suspensions and bans go through AdminService, so they're audited like any other admin action.
+func (s *ModerationService) ResolveReport(ctx context.Context, actor *models.AuditActor, reportID string, req *models.ResolveReportRequest) error {
+	report, err := s.reportRepo.GetReport(ctx, reportID)
+	if err != nil {
+		return err
+	}
+	if report.Status == models.ReportOpen {
+		return errors.New("report must be claimed first")
+	}
+	if report.Status != models.ReportInReview {
+		return errors.New("report already resolved")
+	}
+	if report.ClaimedBy != actor.UserID {
+		return errors.New("report already claimed")
+	}
+
+	reason := req.ReasonForUser
+	if reason == "" {
+		reason = fmt.Sprintf("Violation of community guidelines (%s)", report.Category)
+	}
+
+	switch req.Action {
+	case models.ModerationSuspend:
+		var until *time.Time
+		if req.SuspendHours > 0 {
+			t := time.Now().Add(time.Duration(req.SuspendHours) * time.Hour)
+			until = &t
+		}
+		if err := s.adminService.SuspendUser(ctx, actor, report.ReportedUserID, reason, until); err != nil {
+			return err
+		}
+	case models.ModerationBan:
+		if actor.Role.Rank() < models.RoleAdmin.Rank() {
+			return errors.New("insufficient role")
+		}
+		if err := s.adminService.BanUser(ctx, actor, report.ReportedUserID, reason); err != nil {
+			return err
+		}
+	}
+
+	status := models.ReportResolved
+	if req.Action == models.ModerationDismiss {
+		status = models.ReportDismissed
+	}
+	if err := s.reportRepo.ResolveReport(ctx, reportID, actor.UserID, status, req.Action, req.Note); err != nil {
+		return err
+	}
+
+	s.adminService.audit(ctx, actor, models.AuditReportResolve, report.ReportedUserID, req.Note, map[string]interface{}{
+		"reportId": reportID,
+		"action":   string(req.Action),
+	})
+	return nil
+}
+
+// reporterView hides moderator details from the reporter
+func reporterView(report *models.Report) *models.ReporterReportView {
+	return &models.ReporterReportView{
+		ReportID:       report.ReportID,
+		ReportedUserID: report.ReportedUserID,
+		Username:       report.Snapshot.Username,
+		Category:       report.Category,
+		Status:         report.Status,
+		ActionTaken:    report.Status == models.ReportResolved,
+		CreatedAt:      report.CreatedAt,
+		ResolvedAt:     report.ResolvedAt,
+	}
+}
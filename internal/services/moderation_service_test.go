@@ -0,0 +1,57 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/yourusername/rbd-service/internal/models"
+)
+
+func TestAutoSuspendable(t *testing.T) {
+	now := time.Now()
+	expired := now.Add(-time.Hour)
+	later := now.Add(time.Hour)
+
+	tests := []struct {
+		name      string
+		user      models.User
+		threshold int
+		want      bool
+	}{
+		{"regular user", models.User{}, 5, true},
+		{"threshold disabled", models.User{}, 0, false},
+		{"moderator", models.User{Role: models.RoleModerator}, 5, false},
+		{"admin", models.User{Role: models.RoleAdmin}, 5, false},
+		{"already suspended", models.User{Status: models.AccountSuspended, StatusUntil: &later}, 5, false},
+		{"banned", models.User{Status: models.AccountBanned}, 5, false},
+		{"suspension has ended", models.User{Status: models.AccountSuspended, StatusUntil: &expired}, 5, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := autoSuspendable(&tt.user, tt.threshold, now); got != tt.want {
+				t.Fatalf("autoSuspendable = %v, expected %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReportThreshold(t *testing.T) {
+	tests := []struct {
+		env  string
+		want int
+	}{
+		{"", defaultReportThreshold},
+		{"3", 3},
+		{"0", 0},
+		{"-1", defaultReportThreshold},
+		{"many", defaultReportThreshold},
+	}
+
+	for _, tt := range tests {
+		t.Setenv("REPORT_AUTO_SUSPEND_THRESHOLD", tt.env)
+		if got := reportThreshold(); got != tt.want {
+			t.Errorf("REPORT_AUTO_SUSPEND_THRESHOLD=%q: got %d, expected %d", tt.env, got, tt.want)
+		}
+	}
+}
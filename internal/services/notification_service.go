@@ -4,19 +4,33 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"log"
+	"log/slog"
+	"strings"
+	"time"
 
 	"firebase.google.com/go/messaging"
+	"github.com/yourusername/rbd-service/internal/audit"
 	"github.com/yourusername/rbd-service/internal/config"
+	"github.com/yourusername/rbd-service/internal/metrics"
 	"github.com/yourusername/rbd-service/internal/models"
 	"github.com/yourusername/rbd-service/internal/repository"
+	"github.com/yourusername/rbd-service/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// responseCooldown is how long a user must wait between replies to the same friend
+const responseCooldown = 1 * time.Minute
+
+// fcmSendTimeout bounds a single push so a hung FCM call can't hold a request open for long
+const fcmSendTimeout = 10 * time.Second
+
 type NotificationService struct {
 	userRepo     *repository.UserRepository
 	friendRepo   *repository.FriendRepository
 	cooldownRepo *repository.CooldownRepository
 	historyRepo  *repository.HistoryRepository
+	statsRepo    *repository.StatsRepository
 }
 
 func NewNotificationService() *NotificationService {
@@ -25,16 +39,58 @@ func NewNotificationService() *NotificationService {
 		friendRepo:   repository.NewFriendRepository(),
 		cooldownRepo: repository.NewCooldownRepository(),
 		historyRepo:  repository.NewHistoryRepository(),
+		statsRepo:    repository.NewStatsRepository(),
 	}
 }
 
 // TriggerNotification triggers a notification to a friend
-func (s *NotificationService) TriggerNotification(ctx context.Context, senderID, targetUserID string) (*models.TriggerNotificationResponse, error) {
+func (s *NotificationService) TriggerNotification(ctx context.Context, senderID, targetUserID string) (_ *models.TriggerNotificationResponse, err error) {
+	ctx, span := tracer.Start(ctx, "NotificationService.TriggerNotification", trace.WithAttributes(attribute.String("user.id", senderID)))
+	defer tracing.End(span, &err)
+
+	response, err := s.trigger(ctx, senderID, targetUserID)
+	outcome := triggerOutcome(err)
+	metrics.Triggers.WithLabelValues(outcome).Inc()
+	span.SetAttributes(attribute.String("target.id", targetUserID), attribute.String("trigger.outcome", outcome))
+	return response, err
+}
+
+// triggerOutcome maps the result of a trigger attempt to its metric label
+func triggerOutcome(err error) string {
+	if err == nil {
+		return "sent"
+	}
+	var restriction *models.AccountRestriction
+	if errors.As(err, &restriction) {
+		return "sender_restricted"
+	}
+	switch {
+	case strings.HasPrefix(err.Error(), "cooldown_active:"):
+		return "cooldown"
+	case err.Error() == "friend_muted_you":
+		return "muted"
+	case err.Error() == "user_muted_all":
+		return "muted_all"
+	case err.Error() == "users are not friends":
+		return "not_friends"
+	case err.Error() == "user_unavailable":
+		return "target_restricted"
+	case err.Error() == "sender not found", err.Error() == "target user not found":
+		return "not_found"
+	default:
+		return "error"
+	}
+}
+
+func (s *NotificationService) trigger(ctx context.Context, senderID, targetUserID string) (*models.TriggerNotificationResponse, error) {
 	// Get sender info
 	sender, err := s.userRepo.GetUserByID(ctx, senderID)
 	if err != nil {
 		return nil, errors.New("sender not found")
 	}
+	if restriction := sender.Restriction(time.Now()); restriction != nil {
+		return nil, restriction
+	}
 
 	// Get target user
 	target, err := s.userRepo.GetUserByID(ctx, targetUserID)
@@ -42,6 +98,11 @@ func (s *NotificationService) TriggerNotification(ctx context.Context, senderID,
 		return nil, errors.New("target user not found")
 	}
 
+	// Suspended, banned and soon-to-be-deleted users can't receive triggers either
+	if target.Restriction(time.Now()) != nil || target.DeletionScheduledFor != nil {
+		return nil, errors.New("user_unavailable")
+	}
+
 	// Check if users are friends
 	friendship, err := s.friendRepo.CheckExistingFriendship(ctx, senderID, targetUserID)
 	if err != nil {
@@ -99,18 +160,30 @@ func (s *NotificationService) TriggerNotification(ctx context.Context, senderID,
 	}
 
 	// Create history record
-	if err := s.historyRepo.CreateHistory(ctx, senderID, targetUserID, sender.Username); err != nil {
-		log.Printf("Failed to create history: %v", err)
+	historyID, err := s.historyRepo.CreateHistory(ctx, senderID, targetUserID, sender.Username)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to create history", "sender_id", senderID, "target_id", targetUserID, "error", err)
+	}
+
+	audit.UserAction(ctx, senderID, models.AuditTriggerSent, targetUserID, nil, map[string]interface{}{
+		"historyId":       historyID,
+		"cooldownMinutes": cooldownMinutes,
+	})
+
+	// Update stats aggregates (best effort)
+	if err := s.statsRepo.RecordTrigger(ctx, senderID, targetUserID, time.Now()); err != nil {
+		slog.ErrorContext(ctx, "failed to update stats", "sender_id", senderID, "target_id", targetUserID, "error", err)
 	}
 
 	// Send FCM notification
 	if target.FCMToken != "" {
-		if err := s.sendFCMNotification(ctx, target.FCMToken, sender.Username, senderID); err != nil {
-			log.Printf("⚠️ Failed to send FCM to %s: %v", targetUserID, err)
+		badge := s.unreadBadge(ctx, targetUserID)
+		if err := s.sendFCMNotification(ctx, target.FCMToken, sender.Username, senderID, historyID, badge); err != nil {
+			slog.WarnContext(ctx, "failed to send trigger notification", "target_id", targetUserID, "error", err)
 			// Note: If token is invalid, user needs to re-login to update it
 		}
 	} else {
-		log.Printf("⚠️ Target user %s has no FCM token", targetUserID)
+		slog.WarnContext(ctx, "target user has no FCM token", "target_id", targetUserID)
 	}
 
 	// Calculate next available time
@@ -124,7 +197,8 @@ func (s *NotificationService) TriggerNotification(ctx context.Context, senderID,
 	}
 
 	response := &models.TriggerNotificationResponse{
-		Success: true,
+		Success:   true,
+		HistoryID: historyID,
 	}
 	if nextAvailable != nil {
 		response.NextAvailableAt = nextAvailable.ExpiresAt
@@ -134,12 +208,7 @@ func (s *NotificationService) TriggerNotification(ctx context.Context, senderID,
 }
 
 // sendFCMNotification sends a push notification via FCM
-func (s *NotificationService) sendFCMNotification(ctx context.Context, fcmToken, senderUsername, senderID string) error {
-	client, err := config.FirebaseApp.Messaging(ctx)
-	if err != nil {
-		return fmt.Errorf("failed to get messaging client: %w", err)
-	}
-
+func (s *NotificationService) sendFCMNotification(ctx context.Context, fcmToken, senderUsername, senderID, historyID string, badge *int) error {
 	message := &messaging.Message{
 		Token: fcmToken,
 		Notification: &messaging.Notification{
@@ -162,23 +231,79 @@ func (s *NotificationService) sendFCMNotification(ctx context.Context, fcmToken,
 			Payload: &messaging.APNSPayload{
 				Aps: &messaging.Aps{
 					Sound: "respawn_sound.mp3",
-					Badge: nil,
+					Badge: badge,
 				},
 			},
 		},
 	}
 
-	_, err = client.Send(ctx, message)
+	// History is best effort; without a record there's nothing for the client to acknowledge
+	if historyID != "" {
+		message.Data["historyId"] = historyID
+	}
+
+	if err := s.send(ctx, "trigger", message); err != nil {
+		return err
+	}
+
+	slog.DebugContext(ctx, "trigger notification sent", "sender_id", senderID, "history_id", historyID)
+	return nil
+}
+
+// send delivers a message via FCM and records the result for the given notification kind
+func (s *NotificationService) send(ctx context.Context, kind string, message *messaging.Message) (err error) {
+	// A client hanging up mustn't cancel a push for a trigger that has already been recorded
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), fcmSendTimeout)
+	defer cancel()
+
+	ctx, span := tracer.Start(ctx, "fcm.send", trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attribute.String("fcm.kind", kind)))
+	defer tracing.End(span, &err)
+
+	result := "success"
+	defer func() {
+		metrics.FCMSends.WithLabelValues(kind, result).Inc()
+		span.SetAttributes(attribute.String("fcm.result", result))
+	}()
+
+	client, err := config.FirebaseApp.Messaging(ctx)
 	if err != nil {
+		result = "client_unavailable"
+		return fmt.Errorf("failed to get messaging client: %w", err)
+	}
+
+	if _, err := client.Send(ctx, message); err != nil {
+		result = fcmErrorClass(err)
 		return fmt.Errorf("failed to send FCM: %w", err)
 	}
 
-	log.Printf("✅ Notification sent to token: %s", fcmToken[:20]+"...")
 	return nil
 }
 
+// fcmErrorClass buckets an FCM send error into a low-cardinality metric label
+func fcmErrorClass(err error) string {
+	switch {
+	case messaging.IsRegistrationTokenNotRegistered(err):
+		return "unregistered"
+	case messaging.IsInvalidArgument(err):
+		return "invalid_argument"
+	case messaging.IsMessageRateExceeded(err):
+		return "rate_exceeded"
+	case messaging.IsServerUnavailable(err):
+		return "unavailable"
+	case messaging.IsInternal(err):
+		return "internal"
+	case messaging.IsMismatchedCredential(err), messaging.IsInvalidAPNSCredentials(err):
+		return "credentials"
+	default:
+		return "other"
+	}
+}
+
 // CheckCooldown checks if there's an active cooldown
-func (s *NotificationService) CheckCooldown(ctx context.Context, senderID, targetUserID string) (*models.CooldownResponse, error) {
+func (s *NotificationService) CheckCooldown(ctx context.Context, senderID, targetUserID string) (_ *models.CooldownResponse, err error) {
+	ctx, span := tracer.Start(ctx, "NotificationService.CheckCooldown", trace.WithAttributes(attribute.String("user.id", senderID)))
+	defer tracing.End(span, &err)
+
 	cooldown, err := s.cooldownRepo.CheckActiveCooldown(ctx, senderID, targetUserID)
 	if err != nil {
 		return nil, err
@@ -196,3 +321,247 @@ func (s *NotificationService) CheckCooldown(ctx context.Context, senderID, targe
 		AvailableAt: &cooldown.ExpiresAt,
 	}, nil
 }
+
+// AcknowledgeTrigger records that the receiver's device delivered, opened or responded to a trigger
+func (s *NotificationService) AcknowledgeTrigger(ctx context.Context, userID, historyID string, state models.TriggerState) (_ *models.History, err error) {
+	ctx, span := tracer.Start(ctx, "NotificationService.AcknowledgeTrigger", trace.WithAttributes(attribute.String("user.id", userID)))
+	defer tracing.End(span, &err)
+
+	history, err := s.historyRepo.GetHistoryByID(ctx, historyID)
+	if err != nil {
+		return nil, errors.New("trigger not found")
+	}
+
+	// Only the receiver can acknowledge a trigger, and responses aren't triggers
+	if history.ReceiverID != userID || history.Type != models.HistoryTypeTrigger {
+		return nil, errors.New("trigger not found")
+	}
+
+	updated, advanced, err := s.historyRepo.UpdateTriggerState(ctx, historyID, state)
+	if err != nil {
+		return nil, err
+	}
+	if advanced {
+		s.recordResponseTime(ctx, updated)
+	}
+
+	// Let the sender know, but only when something actually changed
+	if advanced {
+		receiver, err := s.userRepo.GetUserByID(ctx, userID)
+		if err != nil {
+			return updated, nil
+		}
+		sender, err := s.userRepo.GetUserByID(ctx, updated.SenderID)
+		if err != nil || sender.FCMToken == "" {
+			return updated, nil
+		}
+		if err := s.sendAckNotification(ctx, sender.FCMToken, updated, receiver.Username); err != nil {
+			slog.WarnContext(ctx, "failed to send acknowledgement", "target_id", updated.SenderID, "error", err)
+		}
+	}
+
+	return updated, nil
+}
+
+// sendAckNotification sends a silent data message telling the sender their trigger was acknowledged
+func (s *NotificationService) sendAckNotification(ctx context.Context, fcmToken string, history *models.History, receiverUsername string) error {
+	message := &messaging.Message{
+		Token: fcmToken,
+		Data: map[string]string{
+			"type":             "trigger_ack",
+			"historyId":        history.HistoryID,
+			"state":            string(history.State),
+			"receiverId":       history.ReceiverID,
+			"receiverUsername": receiverUsername,
+		},
+		Android: &messaging.AndroidConfig{
+			Priority: "high",
+		},
+		APNS: &messaging.APNSConfig{
+			Payload: &messaging.APNSPayload{
+				Aps: &messaging.Aps{
+					ContentAvailable: true,
+				},
+			},
+		},
+	}
+
+	return s.send(ctx, "ack", message)
+}
+
+// RespondToTrigger sends a short reply back to the sender of a received trigger
+func (s *NotificationService) RespondToTrigger(ctx context.Context, userID, historyID string, response models.ResponseKind) (_ *models.RespondTriggerResponse, err error) {
+	ctx, span := tracer.Start(ctx, "NotificationService.RespondToTrigger", trace.WithAttributes(attribute.String("user.id", userID)))
+	defer tracing.End(span, &err)
+
+	trigger, err := s.historyRepo.GetHistoryByID(ctx, historyID)
+	if err != nil {
+		return nil, errors.New("trigger not found")
+	}
+
+	// Only the receiver of a trigger can reply to it
+	if trigger.ReceiverID != userID || trigger.Type != models.HistoryTypeTrigger {
+		return nil, errors.New("trigger not found")
+	}
+	if trigger.State == models.TriggerStateResponded {
+		return nil, errors.New("already_responded")
+	}
+
+	responder, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, errors.New("sender not found")
+	}
+
+	// The reply goes to whoever sent the trigger
+	target, err := s.userRepo.GetUserByID(ctx, trigger.SenderID)
+	if err != nil {
+		return nil, errors.New("target user not found")
+	}
+
+	friendship, err := s.friendRepo.CheckExistingFriendship(ctx, userID, target.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if friendship == nil || friendship.Status != models.StatusAccepted {
+		return nil, errors.New("users are not friends")
+	}
+
+	// Respect the original sender's mute settings
+	targetMutedResponder := friendship.User2Muted
+	if friendship.User1ID == target.UserID {
+		targetMutedResponder = friendship.User1Muted
+	}
+	if targetMutedResponder {
+		return nil, errors.New("friend_muted_you")
+	}
+	if target.MutedAll {
+		return nil, errors.New("user_muted_all")
+	}
+
+	activeCooldown, err := s.cooldownRepo.CheckActiveResponseCooldown(ctx, userID, target.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if activeCooldown != nil {
+		return nil, fmt.Errorf("cooldown_active:%s", activeCooldown.ExpiresAt.Format("2006-01-02T15:04:05Z07:00"))
+	}
+
+	responseID, err := s.historyRepo.CreateResponse(ctx, userID, target.UserID, responder.Username, historyID, response)
+	if err != nil {
+		return nil, err
+	}
+
+	// The reply is recorded by now, so a failed cooldown write shouldn't make it look unsent
+	if err := s.cooldownRepo.CreateResponseCooldown(ctx, userID, target.UserID, responseCooldown); err != nil {
+		slog.ErrorContext(ctx, "failed to create response cooldown", "target_id", target.UserID, "error", err)
+	}
+
+	audit.UserAction(ctx, userID, models.AuditTriggerResponded, target.UserID, nil, map[string]interface{}{
+		"historyId": responseID,
+		"response":  response,
+	})
+
+	if updated, advanced, err := s.historyRepo.UpdateTriggerState(ctx, historyID, models.TriggerStateResponded); err != nil {
+		slog.ErrorContext(ctx, "failed to mark trigger as responded", "history_id", historyID, "error", err)
+	} else if advanced {
+		s.recordResponseTime(ctx, updated)
+	}
+
+	if target.FCMToken != "" {
+		badge := s.unreadBadge(ctx, target.UserID)
+		if err := s.sendResponseNotification(ctx, target.FCMToken, responder, historyID, responseID, response, badge); err != nil {
+			slog.WarnContext(ctx, "failed to send response notification", "target_id", target.UserID, "error", err)
+		}
+	}
+
+	return &models.RespondTriggerResponse{
+		Success:         true,
+		HistoryID:       responseID,
+		NextAvailableAt: time.Now().Add(responseCooldown),
+	}, nil
+}
+
+// sendResponseNotification pushes a reply back to the original trigger sender
+func (s *NotificationService) sendResponseNotification(ctx context.Context, fcmToken string, responder *models.User, triggerID, responseID string, response models.ResponseKind, badge *int) error {
+	message := &messaging.Message{
+		Token: fcmToken,
+		Notification: &messaging.Notification{
+			Title: responder.Username,
+			Body:  response.Message(),
+		},
+		Data: map[string]string{
+			"type":           "trigger_response",
+			"historyId":      responseID,
+			"responseTo":     triggerID,
+			"response":       string(response),
+			"senderId":       responder.UserID,
+			"senderUsername": responder.Username,
+		},
+		Android: &messaging.AndroidConfig{
+			Priority: "high",
+		},
+		APNS: &messaging.APNSConfig{
+			Payload: &messaging.APNSPayload{
+				Aps: &messaging.Aps{
+					Badge: badge,
+				},
+			},
+		},
+	}
+
+	return s.send(ctx, "response", message)
+}
+
+// GetInbox returns a page of everything the user has received across all friends
+func (s *NotificationService) GetInbox(ctx context.Context, userID, cursor string, limit int) (_ *models.InboxResponse, err error) {
+	ctx, span := tracer.Start(ctx, "NotificationService.GetInbox", trace.WithAttributes(attribute.String("user.id", userID)))
+	defer tracing.End(span, &err)
+
+	items, nextCursor, err := s.historyRepo.GetInbox(ctx, userID, cursor, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	unread, err := s.historyRepo.CountUnread(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.InboxResponse{
+		Items:       items,
+		NextCursor:  nextCursor,
+		UnreadCount: unread,
+	}, nil
+}
+
+// MarkRead marks inbox entries as read and returns the remaining unread count
+func (s *NotificationService) MarkRead(ctx context.Context, userID string, historyIDs []string) (_ int, err error) {
+	ctx, span := tracer.Start(ctx, "NotificationService.MarkRead", trace.WithAttributes(attribute.String("user.id", userID)))
+	defer tracing.End(span, &err)
+
+	if err := s.historyRepo.MarkRead(ctx, userID, historyIDs); err != nil {
+		return 0, err
+	}
+	return s.historyRepo.CountUnread(ctx, userID)
+}
+
+// recordResponseTime adds a newly answered trigger to the receiver's response time stats (best effort)
+func (s *NotificationService) recordResponseTime(ctx context.Context, history *models.History) {
+	if history.State != models.TriggerStateResponded || history.RespondedAt == nil {
+		return
+	}
+	took := history.RespondedAt.Sub(history.TriggeredAt)
+	if err := s.statsRepo.RecordResponse(ctx, history.ReceiverID, history.SenderID, took); err != nil {
+		slog.ErrorContext(ctx, "failed to update response stats", "error", err)
+	}
+}
+
+// unreadBadge returns the APNS badge count for a user, or nil if it can't be determined
+func (s *NotificationService) unreadBadge(ctx context.Context, userID string) *int {
+	unread, err := s.historyRepo.CountUnread(ctx, userID)
+	if err != nil {
+		slog.WarnContext(ctx, "failed to count unread history", "user_id", userID, "error", err)
+		return nil
+	}
+	return &unread
+}
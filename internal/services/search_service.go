@@ -0,0 +1,81 @@
+package services
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/yourusername/rbd-service/internal/health"
+	"github.com/yourusername/rbd-service/internal/metrics"
+	"github.com/yourusername/rbd-service/internal/models"
+	"github.com/yourusername/rbd-service/internal/repository"
+	"github.com/yourusername/rbd-service/internal/search"
+)
+
+// searchIndexRefreshInterval is how often each instance rebuilds its search index from Firestore,
+// picking up registrations, renames and deletions made on other instances
+const searchIndexRefreshInterval = 15 * time.Minute
+
+// RunSearchIndexRefresh builds the search index and then rebuilds it every searchIndexRefreshInterval
+func RunSearchIndexRefresh(ctx context.Context) {
+	BuildSearchIndex(ctx)
+
+	health.WatchWorker("search_index_refresh", 3*searchIndexRefreshInterval)
+	ticker := time.NewTicker(searchIndexRefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		start := time.Now()
+		err := RebuildSearchIndex(ctx)
+		metrics.ObserveJob("search_index_refresh", start, err)
+		health.Beat("search_index_refresh")
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to rebuild search index", "error", err)
+		}
+	}
+}
+
+// BuildSearchIndex builds the search index at startup, retrying with backoff until it succeeds
+// or ctx is done. Readiness fails until the index is built.
+func BuildSearchIndex(ctx context.Context) {
+	backoff := 5 * time.Second
+	for {
+		err := RebuildSearchIndex(ctx)
+		if err == nil {
+			return
+		}
+		slog.ErrorContext(ctx, "failed to build search index", "error", err, "retry_in", backoff)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, 5*time.Minute)
+	}
+}
+
+// RebuildSearchIndex loads every user from Firestore into the search index.
+// Restricted and soon-to-be-deleted users are indexed too; search filters them out at query time,
+// so they show up again as soon as a suspension lifts or a deletion is cancelled on any instance.
+// Until it finishes, user search falls back to Firestore prefix queries.
+func RebuildSearchIndex(ctx context.Context) error {
+	usernames := make(map[string]string)
+	err := repository.NewUserRepository().ForEachUser(ctx, func(user *models.User) error {
+		usernames[user.UserID] = user.Username
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	search.GetIndex().Replace(usernames)
+	slog.InfoContext(ctx, "search index built", "users", len(usernames))
+	return nil
+}
@@ -0,0 +1,71 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/yourusername/rbd-service/internal/models"
+	"github.com/yourusername/rbd-service/internal/repository"
+)
+
+// topFriendsLimit is how many friends are listed in the overall stats
+const topFriendsLimit = 5
+
+type StatsService struct {
+	statsRepo   *repository.StatsRepository
+	userRepo    *repository.UserRepository
+	friendRepo  *repository.FriendRepository
+	historyRepo *repository.HistoryRepository
+}
+
+func NewStatsService() *StatsService {
+	return &StatsService{
+		statsRepo:   repository.NewStatsRepository(),
+		userRepo:    repository.NewUserRepository(),
+		friendRepo:  repository.NewFriendRepository(),
+		historyRepo: repository.NewHistoryRepository(),
+	}
+}
+
+// GetUserStats returns the user's overall trigger statistics
+func (s *StatsService) GetUserStats(ctx context.Context, userID string) (*models.UserStatsResponse, error) {
+	stats, err := s.statsRepo.GetUserStats(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	topFriends := []*models.TopFriend{}
+	for _, friendUserID := range stats.TopFriendIDs(topFriendsLimit) {
+		user, err := s.userRepo.GetUserByID(ctx, friendUserID)
+		if err != nil {
+			continue // Skip if user not found
+		}
+		topFriends = append(topFriends, &models.TopFriend{
+			UserID:   friendUserID,
+			Username: user.Username,
+			Count:    stats.Friends[friendUserID],
+		})
+	}
+
+	return &models.UserStatsResponse{
+		StatsSummary: *stats.Summary(time.Now()),
+		TopFriends:   topFriends,
+	}, nil
+}
+
+// GetFriendStats returns trigger statistics between the user and one friend
+func (s *StatsService) GetFriendStats(ctx context.Context, userID, friendUserID string) (*models.FriendStatsResponse, error) {
+	if err := checkFriendAccess(ctx, s.friendRepo, s.historyRepo, userID, friendUserID); err != nil {
+		return nil, err
+	}
+
+	stats, err := s.statsRepo.GetFriendStats(ctx, userID, friendUserID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.FriendStatsResponse{
+		StatsSummary: *stats.Summary(time.Now()),
+		FriendUserID: friendUserID,
+	}, nil
+}
@@ -1,12 +1,18 @@
 package services
 
 import (
+	"context"
+	"sort"
 	"sync"
 	"time"
+
+	"github.com/yourusername/rbd-service/internal/health"
+	"github.com/yourusername/rbd-service/internal/metrics"
 )
 
 type TokenInfo struct {
 	UserID    string
+	CreatedAt time.Time
 	ExpiresAt time.Time
 }
 
@@ -27,8 +33,6 @@ func GetTokenStore() *TokenStore {
 		tokenStore = &TokenStore{
 			tokens: make(map[string]*TokenInfo),
 		}
-		// Start cleanup goroutine
-		go tokenStore.cleanupExpiredTokens()
 	})
 	return tokenStore
 }
@@ -39,6 +43,7 @@ func (ts *TokenStore) StoreToken(token, userID string) {
 	defer ts.mu.Unlock()
 	ts.tokens[token] = &TokenInfo{
 		UserID:    userID,
+		CreatedAt: time.Now(),
 		ExpiresAt: time.Now().Add(30 * 24 * time.Hour), // 30 days
 	}
 }
@@ -65,6 +70,38 @@ func (ts *TokenStore) DeleteToken(token string) {
 	delete(ts.tokens, token)
 }
 
+// RevokeUserTokens removes every token belonging to a user except the given one (pass "" to revoke all).
+// Returns the number of tokens revoked.
+func (ts *TokenStore) RevokeUserTokens(userID, exceptToken string) int {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	revoked := 0
+	for token, info := range ts.tokens {
+		if info.UserID == userID && token != exceptToken {
+			delete(ts.tokens, token)
+			revoked++
+		}
+	}
+	return revoked
+}
+
+// ListUserSessions returns copies of a user's active sessions, oldest first
+func (ts *TokenStore) ListUserSessions(userID string) []TokenInfo {
+	ts.mu.RLock()
+	defer ts.mu.RUnlock()
+	now := time.Now()
+	var sessions []TokenInfo
+	for _, info := range ts.tokens {
+		if info.UserID == userID && now.Before(info.ExpiresAt) {
+			sessions = append(sessions, *info)
+		}
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].CreatedAt.Before(sessions[j].CreatedAt)
+	})
+	return sessions
+}
+
 // RefreshToken extends the expiration time of an existing token
 func (ts *TokenStore) RefreshToken(token string) bool {
 	ts.mu.Lock()
@@ -83,19 +120,50 @@ func (ts *TokenStore) RefreshToken(token string) bool {
 	return true
 }
 
-// cleanupExpiredTokens removes expired tokens periodically
+// ActiveSessions returns the number of unexpired tokens
+func (ts *TokenStore) ActiveSessions() int {
+	ts.mu.RLock()
+	defer ts.mu.RUnlock()
+	now := time.Now()
+	active := 0
+	for _, info := range ts.tokens {
+		if now.Before(info.ExpiresAt) {
+			active++
+		}
+	}
+	return active
+}
+
+// cleanupExpiredTokens removes expired tokens
 func (ts *TokenStore) cleanupExpiredTokens() {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	now := time.Now()
+	for token, info := range ts.tokens {
+		if now.After(info.ExpiresAt) {
+			delete(ts.tokens, token)
+		}
+	}
+}
+
+// RunTokenCleanup removes expired tokens from the session store hourly until ctx is cancelled
+func RunTokenCleanup(ctx context.Context) {
+	ts := GetTokenStore()
+
+	health.WatchWorker("token_cleanup", 3*time.Hour)
 	ticker := time.NewTicker(1 * time.Hour)
 	defer ticker.Stop()
-	
-	for range ticker.C {
-		ts.mu.Lock()
-		now := time.Now()
-		for token, info := range ts.tokens {
-			if now.After(info.ExpiresAt) {
-				delete(ts.tokens, token)
-			}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
 		}
-		ts.mu.Unlock()
+
+		start := time.Now()
+		ts.cleanupExpiredTokens()
+		metrics.ObserveJob("token_cleanup", start, nil)
+		health.Beat("token_cleanup")
 	}
 }
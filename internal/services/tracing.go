@@ -0,0 +1,6 @@
+package services
+
+import "github.com/yourusername/rbd-service/internal/tracing"
+
+// tracer creates the spans for service methods
+var tracer = tracing.Tracer("github.com/yourusername/rbd-service/internal/services")
@@ -0,0 +1,241 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/yourusername/rbd-service/internal/audit"
+	"github.com/yourusername/rbd-service/internal/models"
+	"github.com/yourusername/rbd-service/internal/repository"
+	"github.com/yourusername/rbd-service/internal/search"
+	"github.com/yourusername/rbd-service/internal/storage"
+	"github.com/yourusername/rbd-service/pkg/utils"
+)
+
+const (
+	// usernameChangeCooloff is how long a user must wait between renames
+	usernameChangeCooloff = 30 * 24 * time.Hour
+	// usernameGracePeriod is how long a previous username stays reserved for its old owner
+	usernameGracePeriod = 30 * 24 * time.Hour
+)
+
+type UserService struct {
+	userRepo   *repository.UserRepository
+	friendRepo *repository.FriendRepository
+	auditRepo  *repository.AuditRepository
+	blobStore  storage.BlobStore
+}
+
+func NewUserService() *UserService {
+	return &UserService{
+		userRepo:   repository.NewUserRepository(),
+		friendRepo: repository.NewFriendRepository(),
+		auditRepo:  repository.NewAuditRepository(),
+		blobStore:  storage.GetBlobStore(),
+	}
+}
+
+// ChangeUsername renames the user. History keeps showing the name used at the time of each trigger.
+func (s *UserService) ChangeUsername(ctx context.Context, userID, newUsername string) (*models.User, error) {
+	if err := utils.ValidateUsername(newUsername); err != nil {
+		return nil, err
+	}
+
+	user, oldUsername, err := s.userRepo.ChangeUsername(ctx, userID, newUsername, usernameChangeCooloff, usernameGracePeriod)
+	if err != nil {
+		return nil, err
+	}
+
+	search.GetIndex().Upsert(userID, user.Username)
+
+	audit.UserAction(ctx, userID, models.AuditUsernameChange, "",
+		map[string]interface{}{"username": oldUsername},
+		map[string]interface{}{"username": user.Username})
+
+	return user, nil
+}
+
+// GetProfile returns a user's profile. Users can see their own profile and their friends' profiles.
+func (s *UserService) GetProfile(ctx context.Context, viewerID, userID string) (*models.UserProfile, error) {
+	if viewerID != userID {
+		friendship, err := s.friendRepo.CheckExistingFriendship(ctx, viewerID, userID)
+		if err != nil {
+			return nil, err
+		}
+		if friendship == nil || friendship.Status != models.StatusAccepted {
+			return nil, errors.New("user not found")
+		}
+	}
+
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+
+	friendCount, err := s.friendRepo.CountAcceptedFriends(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.UserProfile{
+		UserID:         user.UserID,
+		Username:       user.Username,
+		DisplayName:    user.DisplayName,
+		Bio:            user.Bio,
+		AvatarURL:      user.AvatarURL,
+		AvatarThumbURL: user.AvatarThumbURL,
+		CreatedAt:      user.CreatedAt,
+		FriendCount:    friendCount,
+	}, nil
+}
+
+// UpdateProfile updates the user's display name and bio
+func (s *UserService) UpdateProfile(ctx context.Context, userID string, req *models.UpdateProfileRequest) (*models.UserProfile, error) {
+	if req.DisplayName != nil {
+		name := strings.TrimSpace(*req.DisplayName)
+		if err := validateProfileText(name, 32, "display name"); err != nil {
+			return nil, err
+		}
+		req.DisplayName = &name
+	}
+	if req.Bio != nil {
+		bio := strings.TrimSpace(*req.Bio)
+		if err := validateProfileText(bio, 160, "bio"); err != nil {
+			return nil, err
+		}
+		req.Bio = &bio
+	}
+
+	if err := s.userRepo.UpdateProfile(ctx, userID, req.DisplayName, req.Bio); err != nil {
+		return nil, err
+	}
+
+	return s.GetProfile(ctx, userID, userID)
+}
+
+// UploadAvatar validates and resizes an uploaded image and makes it the user's avatar
+func (s *UserService) UploadAvatar(ctx context.Context, userID string, data []byte) (*models.UserProfile, error) {
+	thumbnails, err := utils.ProcessAvatar(data)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+
+	// A fresh key per upload so clients and CDNs never serve a stale image
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	avatarKey := fmt.Sprintf("avatars/%s/%s", userID, hex.EncodeToString(b))
+
+	urls := make([]string, len(thumbnails))
+	for i, thumbnail := range thumbnails {
+		urls[i], err = s.blobStore.Put(ctx, avatarBlobKey(avatarKey, utils.AvatarSizes[i]), "image/jpeg", thumbnail)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.userRepo.UpdateAvatar(ctx, userID, avatarKey, urls[0], urls[1]); err != nil {
+		return nil, err
+	}
+
+	s.deleteAvatarBlobs(ctx, user.AvatarKey)
+
+	return s.GetProfile(ctx, userID, userID)
+}
+
+// DeleteAvatar removes the user's avatar
+func (s *UserService) DeleteAvatar(ctx context.Context, userID string) error {
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return errors.New("user not found")
+	}
+
+	if err := s.userRepo.UpdateAvatar(ctx, userID, "", "", ""); err != nil {
+		return err
+	}
+
+	s.deleteAvatarBlobs(ctx, user.AvatarKey)
+	return nil
+}
+
+// deleteAvatarBlobs removes every thumbnail of a previous avatar (best effort)
+func (s *UserService) deleteAvatarBlobs(ctx context.Context, avatarKey string) {
+	if avatarKey == "" {
+		return
+	}
+	for _, size := range utils.AvatarSizes {
+		if err := s.blobStore.Delete(ctx, avatarBlobKey(avatarKey, size)); err != nil {
+			slog.WarnContext(ctx, "failed to delete old avatar", "key", avatarKey, "error", err)
+		}
+	}
+}
+
+// avatarBlobKey returns the blob key of one thumbnail size
+func avatarBlobKey(avatarKey string, size int) string {
+	return fmt.Sprintf("%s_%d.jpg", avatarKey, size)
+}
+
+// validateProfileText checks free-form profile text for length and control characters
+func validateProfileText(text string, maxLen int, field string) error {
+	if len([]rune(text)) > maxLen {
+		return fmt.Errorf("%s must be at most %d characters", field, maxLen)
+	}
+	for _, r := range text {
+		if unicode.IsControl(r) && r != '\n' {
+			return fmt.Errorf("%s contains invalid characters", field)
+		}
+	}
+	return nil
+}
+
+// GetActivity returns the audit entries visible to a user, newest first
+func (s *UserService) GetActivity(ctx context.Context, userID, cursor string, limit int) (*models.ActivityResponse, error) {
+	entries, nextCursor, err := s.auditRepo.ListVisibleTo(ctx, userID, cursor, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	activity := make([]*models.ActivityEntry, len(entries))
+	for i, entry := range entries {
+		activity[i] = activityView(entry, userID)
+	}
+
+	return &models.ActivityResponse{
+		Entries:    activity,
+		NextCursor: nextCursor,
+	}, nil
+}
+
+// activityView strips what the viewer shouldn't see: who on staff acted and where they acted from.
+// Network details are kept for the viewer's own actions and for failed logins against their account.
+func activityView(entry *models.AuditEntry, viewerID string) *models.ActivityEntry {
+	view := &models.ActivityEntry{
+		Action:    entry.Action,
+		ByYou:     entry.ActorID == viewerID,
+		Reason:    entry.Reason,
+		Before:    entry.Before,
+		After:     entry.After,
+		CreatedAt: entry.CreatedAt,
+	}
+	if entry.TargetUserID != viewerID {
+		view.TargetUserID = entry.TargetUserID
+	}
+	if view.ByYou || entry.ActorID == models.AuditActorAnonymous {
+		view.IP = entry.IP
+		view.UserAgent = entry.UserAgent
+	}
+	return view
+}
@@ -0,0 +1,86 @@
+package storage
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+
+	gcs "cloud.google.com/go/storage"
+	"github.com/yourusername/rbd-service/internal/config"
+)
+
+// FirebaseStore keeps blobs in a Firebase Storage bucket
+type FirebaseStore struct {
+	bucket     *gcs.BucketHandle
+	bucketName string
+}
+
+// NewFirebaseStore creates a store backed by the named Firebase Storage bucket
+func NewFirebaseStore(ctx context.Context, bucketName string) (*FirebaseStore, error) {
+	if config.FirebaseApp == nil {
+		return nil, errors.New("firebase is not initialized")
+	}
+	if bucketName == "" {
+		return nil, errors.New("FIREBASE_STORAGE_BUCKET is not set")
+	}
+
+	client, err := config.FirebaseApp.Storage(ctx)
+	if err != nil {
+		return nil, err
+	}
+	bucket, err := client.Bucket(bucketName)
+	if err != nil {
+		return nil, err
+	}
+
+	return &FirebaseStore{bucket: bucket, bucketName: bucketName}, nil
+}
+
+// Put uploads data and returns a Firebase download URL
+func (s *FirebaseStore) Put(ctx context.Context, key, contentType string, data []byte) (string, error) {
+	// Firebase serves objects that carry a download token without making the bucket public
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	token := hex.EncodeToString(b)
+
+	w := s.bucket.Object(key).NewWriter(ctx)
+	w.ContentType = contentType
+	w.CacheControl = "public, max-age=31536000"
+	w.Metadata = map[string]string{"firebaseStorageDownloadTokens": token}
+	if _, err := w.Write(data); err != nil {
+		w.Close()
+		return "", err
+	}
+	if err := w.Close(); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("https://firebasestorage.googleapis.com/v0/b/%s/o/%s?alt=media&token=%s",
+		s.bucketName, strings.ReplaceAll(url.PathEscape(key), "/", "%2F"), token), nil
+}
+
+// Get downloads the object under key
+func (s *FirebaseStore) Get(ctx context.Context, key string) ([]byte, error) {
+	r, err := s.bucket.Object(key).NewReader(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
+
+// Delete removes the object under key
+func (s *FirebaseStore) Delete(ctx context.Context, key string) error {
+	err := s.bucket.Object(key).Delete(ctx)
+	if errors.Is(err, gcs.ErrObjectNotExist) {
+		return nil
+	}
+	return err
+}
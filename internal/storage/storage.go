@@ -0,0 +1,71 @@
+package storage
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"sync"
+)
+
+// BlobStore stores binary objects such as avatar images
+type BlobStore interface {
+	// Put stores data under key and returns a URL clients can fetch it from
+	Put(ctx context.Context, key, contentType string, data []byte) (string, error)
+	// Get reads the blob under key
+	Get(ctx context.Context, key string) ([]byte, error)
+	// Delete removes the blob under key (missing blobs are not an error)
+	Delete(ctx context.Context, key string) error
+}
+
+var (
+	blobStore BlobStore
+	once      sync.Once
+
+	exportStore     BlobStore
+	exportStoreOnce sync.Once
+)
+
+// GetBlobStore returns the configured blob store singleton.
+// STORAGE_BACKEND selects "local" (default) or "firebase".
+func GetBlobStore() BlobStore {
+	once.Do(func() {
+		switch os.Getenv("STORAGE_BACKEND") {
+		case "firebase":
+			store, err := NewFirebaseStore(context.Background(), os.Getenv("FIREBASE_STORAGE_BUCKET"))
+			if err != nil {
+				slog.Warn("failed to initialize Firebase Storage, falling back to local storage", "error", err)
+				blobStore = newLocalStoreFromEnv()
+				return
+			}
+			blobStore = store
+		default:
+			blobStore = newLocalStoreFromEnv()
+		}
+	})
+	return blobStore
+}
+
+// GetExportStore returns the store for private blobs such as data exports, which are only served through the API.
+// Locally they live outside the publicly served upload directory (STORAGE_EXPORT_DIR).
+func GetExportStore() BlobStore {
+	exportStoreOnce.Do(func() {
+		if _, ok := GetBlobStore().(*FirebaseStore); ok {
+			exportStore = GetBlobStore()
+			return
+		}
+		dir := os.Getenv("STORAGE_EXPORT_DIR")
+		if dir == "" {
+			dir = "./data/exports"
+		}
+		exportStore = NewLocalStore(dir, "")
+	})
+	return exportStore
+}
+
+func newLocalStoreFromEnv() *LocalStore {
+	dir := os.Getenv("STORAGE_LOCAL_DIR")
+	if dir == "" {
+		dir = "./data/uploads"
+	}
+	return NewLocalStore(dir, os.Getenv("PUBLIC_BASE_URL")+LocalURLPrefix)
+}
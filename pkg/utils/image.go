@@ -0,0 +1,110 @@
+package utils
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+
+	// Register decoders for accepted upload formats
+	_ "image/gif"
+	_ "image/png"
+)
+
+const (
+	// MaxImageUploadBytes is the largest image upload accepted
+	MaxImageUploadBytes = 5 << 20
+	// maxImageDimension guards against decompression bombs
+	maxImageDimension = 4096
+)
+
+// AvatarSizes are the square thumbnail sizes generated for every avatar
+var AvatarSizes = []int{256, 64}
+
+// ProcessAvatar validates an uploaded image and renders it as square JPEG thumbnails,
+// one per entry in AvatarSizes (center-cropped, area-averaged)
+func ProcessAvatar(data []byte) ([][]byte, error) {
+	if len(data) == 0 {
+		return nil, errors.New("image is empty")
+	}
+	if len(data) > MaxImageUploadBytes {
+		return nil, errors.New("image must be at most 5 MB")
+	}
+
+	// Check dimensions before decoding the full image
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, errors.New("image must be a JPEG, PNG or GIF")
+	}
+	if format != "jpeg" && format != "png" && format != "gif" {
+		return nil, errors.New("image must be a JPEG, PNG or GIF")
+	}
+	if cfg.Width < 16 || cfg.Height < 16 || cfg.Width > maxImageDimension || cfg.Height > maxImageDimension {
+		return nil, errors.New("image must be between 16 and 4096 pixels on each side")
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, errors.New("image could not be decoded")
+	}
+
+	square := cropSquare(img.Bounds())
+	var out [][]byte
+	for _, size := range AvatarSizes {
+		var buf bytes.Buffer
+		if err := jpeg.Encode(&buf, resize(img, square, size), &jpeg.Options{Quality: 85}); err != nil {
+			return nil, err
+		}
+		out = append(out, buf.Bytes())
+	}
+
+	return out, nil
+}
+
+// cropSquare returns the centered square region of the given bounds
+func cropSquare(b image.Rectangle) image.Rectangle {
+	side := min(b.Dx(), b.Dy())
+	x := b.Min.X + (b.Dx()-side)/2
+	y := b.Min.Y + (b.Dy()-side)/2
+	return image.Rect(x, y, x+side, y+side)
+}
+
+// resize scales the square src region of img to size x size.
+// Each output pixel is the average of the source pixels it covers; smaller sources are upscaled by nearest neighbour.
+func resize(img image.Image, src image.Rectangle, size int) *image.RGBA {
+	dst := image.NewRGBA(image.Rect(0, 0, size, size))
+	side := src.Dx()
+
+	for dy := 0; dy < size; dy++ {
+		y0 := src.Min.Y + dy*side/size
+		y1 := max(src.Min.Y+(dy+1)*side/size, y0+1)
+		for dx := 0; dx < size; dx++ {
+			x0 := src.Min.X + dx*side/size
+			x1 := max(src.Min.X+(dx+1)*side/size, x0+1)
+
+			var r, g, b, a, n uint64
+			for y := y0; y < y1; y++ {
+				for x := x0; x < x1; x++ {
+					pr, pg, pb, pa := img.At(x, y).RGBA()
+					r += uint64(pr)
+					g += uint64(pg)
+					b += uint64(pb)
+					a += uint64(pa)
+					n++
+				}
+			}
+
+			// JPEG has no alpha, so composite the (premultiplied) pixels over white
+			white := n*0xffff - a
+			dst.Set(dx, dy, color.RGBA64{
+				R: uint16((r + white) / n),
+				G: uint16((g + white) / n),
+				B: uint16((b + white) / n),
+				A: 0xffff,
+			})
+		}
+	}
+
+	return dst
+}
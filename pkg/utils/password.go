@@ -0,0 +1,72 @@
+package utils
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// common_passwords.txt holds the 10,000 most frequent passwords of 8 to 72 characters from the
+// zxcvbn password list (derived from the Xato 10M password corpus), lowercased and sorted.
+// Shorter ones are already rejected by the minimum length.
+//
+//go:embed common_passwords.txt
+var commonPasswordList string
+
+// PasswordPolicy describes the rules new passwords must satisfy
+type PasswordPolicy struct {
+	MinLength    int
+	MaxLength    int  // bcrypt ignores everything after 72 bytes
+	RejectCommon bool // Reject passwords found in the embedded common-password list
+}
+
+var (
+	passwordPolicy     PasswordPolicy
+	commonPasswords    map[string]struct{}
+	passwordPolicyOnce sync.Once
+)
+
+// CurrentPasswordPolicy returns the policy configured via PASSWORD_MIN_LENGTH and PASSWORD_REJECT_COMMON
+func CurrentPasswordPolicy() PasswordPolicy {
+	passwordPolicyOnce.Do(func() {
+		passwordPolicy = PasswordPolicy{
+			MinLength:    8,
+			MaxLength:    72,
+			RejectCommon: true,
+		}
+		if v, err := strconv.Atoi(os.Getenv("PASSWORD_MIN_LENGTH")); err == nil && v > 0 {
+			passwordPolicy.MinLength = v
+		}
+		if v, err := strconv.ParseBool(os.Getenv("PASSWORD_REJECT_COMMON")); err == nil {
+			passwordPolicy.RejectCommon = v
+		}
+
+		commonPasswords = make(map[string]struct{})
+		for _, line := range strings.Split(commonPasswordList, "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				commonPasswords[strings.ToLower(line)] = struct{}{}
+			}
+		}
+	})
+	return passwordPolicy
+}
+
+// Validate checks a password against the policy
+func (p PasswordPolicy) Validate(password string) error {
+	if len(password) < p.MinLength {
+		return fmt.Errorf("password must be at least %d characters", p.MinLength)
+	}
+	if p.MaxLength > 0 && len(password) > p.MaxLength {
+		return fmt.Errorf("password must be at most %d characters", p.MaxLength)
+	}
+	if p.RejectCommon {
+		if _, ok := commonPasswords[strings.ToLower(password)]; ok {
+			return errors.New("password is too common")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCommonPasswordList(t *testing.T) {
+	lines := strings.Split(strings.TrimSpace(commonPasswordList), "\n")
+	if len(lines) < 10000 {
+		t.Fatalf("common password list has %d entries, expected at least 10000", len(lines))
+	}
+	for _, line := range lines {
+		if len(line) < 8 || len(line) > 72 {
+			t.Errorf("%q is outside the 8 to 72 character range", line)
+		}
+		if line != strings.ToLower(strings.TrimSpace(line)) {
+			t.Errorf("%q must be lowercase without surrounding spaces", line)
+		}
+	}
+}
+
+func TestValidateRejectsCommonPasswords(t *testing.T) {
+	policy := PasswordPolicy{MinLength: 8, MaxLength: 72, RejectCommon: true}
+	CurrentPasswordPolicy() // loads the list
+
+	for _, password := range []string{"password1", "Qwerty123", "ILOVEYOU1"} {
+		if err := policy.Validate(password); err == nil || err.Error() != "password is too common" {
+			t.Errorf("Validate(%q) = %v, expected \"password is too common\"", password, err)
+		}
+	}
+	if err := policy.Validate("Correct-Horse-Battery-9"); err != nil {
+		t.Errorf("expected an uncommon password to pass, got %v", err)
+	}
+}
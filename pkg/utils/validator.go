@@ -3,6 +3,7 @@ package utils
 import (
 	"errors"
 	"regexp"
+	"strings"
 )
 
 var usernameRegex = regexp.MustCompile(`^[a-zA-Z0-9_]{3,16}$`)
@@ -18,10 +19,18 @@ func ValidateUsername(username string) error {
 	return nil
 }
 
-// ValidatePassword validates password format
+// ValidatePassword validates a new password against the configured password policy
 func ValidatePassword(password string) error {
-	if len(password) < 6 {
-		return errors.New("password must be at least 6 characters")
+	return CurrentPasswordPolicy().Validate(password)
+}
+
+// ValidatePasswordForUser validates a new password and rejects ones containing the username
+func ValidatePasswordForUser(password, username string) error {
+	if err := ValidatePassword(password); err != nil {
+		return err
+	}
+	if username != "" && strings.Contains(strings.ToLower(password), strings.ToLower(username)) {
+		return errors.New("password must not contain your username")
 	}
 	return nil
 }